@@ -0,0 +1,108 @@
+package bench
+
+import (
+	"benchmark/stats"
+	"crypto/sha256"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// ECDSACurves are the curves supported by the TSM for ECDSA keys
+var ECDSACurves = []string{"secp256k1", "P-224", "P-256", "P-384", "P-521"}
+
+// SchnorrVariants are the Schnorr variants supported by the TSM
+var SchnorrVariants = []string{tsm.SchnorrEd25519, tsm.SchnorrEd448, tsm.SchnorrBIP340, tsm.SchnorrMina, tsm.SchnorrZilliqa, tsm.SchnorrSr25519}
+
+// Algorithm is a signature scheme, ECDSA or Schnorr, along with the curve of the keys. For Schnorr, the curve is the
+// Schnorr variant. An algorithm is written as e.g. ECDSA/P-256 or Schnorr/BIP-340.
+type Algorithm struct {
+	Scheme string
+	Curve  string
+}
+
+// ParseAlgorithm parses an algorithm such as ECDSA/P-256 or Schnorr/BIP-340. For compatibility with the -ecdsaClients
+// and -ed25519Clients flags, ECDSA is short for ECDSA/secp256k1, and Ed25519 is short for Schnorr/Ed25519.
+func ParseAlgorithm(s string) (Algorithm, error) {
+	switch s {
+	case "ECDSA":
+		return Algorithm{"ECDSA", "secp256k1"}, nil
+	case "Ed25519":
+		return Algorithm{"Schnorr", tsm.SchnorrEd25519}, nil
+	}
+	scheme, curve, _ := strings.Cut(s, "/")
+	a := Algorithm{scheme, curve}
+	switch {
+	case scheme == "ECDSA" && slices.Contains(ECDSACurves, curve):
+	case scheme == "Schnorr" && slices.Contains(SchnorrVariants, curve):
+	default:
+		return Algorithm{}, fmt.Errorf("invalid algorithm: %s", s)
+	}
+	return a, nil
+}
+
+func (a Algorithm) String() string {
+	return a.Scheme + "/" + a.Curve
+}
+
+func (a Algorithm) isECDSA() bool {
+	return a.Scheme == "ECDSA"
+}
+
+// Returns the input to Sign for the message signed by the benchmark: the SHA-256 hash of the message for ECDSA, and
+// the message itself for Schnorr
+func (a Algorithm) signInput() []byte {
+	message := []byte("This is the message that will be signed!")
+	switch {
+	case a.isECDSA():
+		messageHash := sha256.Sum256(message)
+		return messageHash[:]
+	case a.Curve == tsm.SchnorrMina:
+		return tsm.SchnorrMinaPrepareMessage(message)
+	default:
+		return message
+	}
+}
+
+// Returns the algorithm in a form usable in file names, such as ecdsa-p-256
+func (a Algorithm) fileName() string {
+	return strings.ToLower(a.Scheme + "-" + a.Curve)
+}
+
+// The clients of an algorithm, along with the benchmark key and the counters of the algorithm
+type algorithmState struct {
+	Algorithm
+	clients      int
+	keyID        string
+	operations   uint64
+	errors       uint64
+	invalid      uint64
+	inconsistent uint64
+	dropped      uint64
+	late         uint64
+	timeouts     uint64
+	// Nanoseconds spent in sessions that failed, including those that timed out
+	lost     int64
+	latency  *stats.Histogram
+	failures failureCounts
+}
+
+// Returns one latency histogram per client of each algorithm. When the clients are done, mergeLatencies merges the
+// histograms of each algorithm into the algorithm.
+func (b *Benchmark) clientLatencies() map[*algorithmState][]*stats.Histogram {
+	latencies := map[*algorithmState][]*stats.Histogram{}
+	for _, a := range b.algorithms {
+		for i := 0; i < a.clients; i++ {
+			latencies[a] = append(latencies[a], stats.NewHistogram())
+		}
+	}
+	return latencies
+}
+
+func mergeLatencies(latencies map[*algorithmState][]*stats.Histogram) {
+	for a, l := range latencies {
+		a.latency = stats.Merge(l...)
+	}
+}
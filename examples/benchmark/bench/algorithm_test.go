@@ -0,0 +1,77 @@
+package bench
+
+import (
+	"testing"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+func TestParseAlgorithm(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Algorithm
+		wantErr bool
+	}{
+		{in: "ECDSA", want: Algorithm{"ECDSA", "secp256k1"}},
+		{in: "Ed25519", want: Algorithm{"Schnorr", tsm.SchnorrEd25519}},
+		{in: "ECDSA/P-256", want: Algorithm{"ECDSA", "P-256"}},
+		{in: "ECDSA/secp256k1", want: Algorithm{"ECDSA", "secp256k1"}},
+		{in: "Schnorr/Ed25519", want: Algorithm{"Schnorr", tsm.SchnorrEd25519}},
+		{in: "Schnorr/BIP-340", want: Algorithm{"Schnorr", tsm.SchnorrBIP340}},
+		{in: "Schnorr/ED-25519", wantErr: true},
+		{in: "ECDSA/Ed25519", wantErr: true},
+		{in: "Schnorr/P-256", wantErr: true},
+		{in: "ECDSA/", wantErr: true},
+		{in: "Schnorr", wantErr: true},
+		{in: "RSA/2048", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAlgorithm(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAlgorithm(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAlgorithm(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseAlgorithm(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Every curve and variant listed in the usage of the -clients flag can be parsed back from its name
+func TestParseAlgorithmString(t *testing.T) {
+	var algorithms []Algorithm
+	for _, curve := range ECDSACurves {
+		algorithms = append(algorithms, Algorithm{"ECDSA", curve})
+	}
+	for _, variant := range SchnorrVariants {
+		algorithms = append(algorithms, Algorithm{"Schnorr", variant})
+	}
+	for _, a := range algorithms {
+		got, err := ParseAlgorithm(a.String())
+		if err != nil || got != a {
+			t.Errorf("ParseAlgorithm(%q) = %v, %v", a.String(), got, err)
+		}
+	}
+}
+
+func TestAlgorithmFileName(t *testing.T) {
+	tests := []struct {
+		a    Algorithm
+		want string
+	}{
+		{Algorithm{"ECDSA", "P-256"}, "ecdsa-p-256"},
+		{Algorithm{"Schnorr", tsm.SchnorrBIP340}, "schnorr-bip-340"},
+	}
+	for _, tt := range tests {
+		if got := tt.a.fileName(); got != tt.want {
+			t.Errorf("%v.fileName() = %q, want %q", tt.a, got, tt.want)
+		}
+	}
+}
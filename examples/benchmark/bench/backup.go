@@ -0,0 +1,137 @@
+package bench
+
+import (
+	"benchmark/random"
+	"benchmark/test"
+	"bytes"
+	"context"
+	"fmt"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Checks that key share backups can be restored. A key share is always restored under the key ID it was backed up
+// with, so the generated key is first copied to a new key ID, and the backups of the copy are restored after its
+// shares have been deleted. This leaves the original key untouched if the restore fails.
+func (b *Benchmark) backupDrill(a Algorithm, d *DrillResult) error {
+	var keyID, restoreKeyID string
+	var publicKey []byte
+	var err error
+	defer func() {
+		for _, id := range []string{keyID, restoreKeyID} {
+			if id == "" {
+				continue
+			}
+			if err := b.deleteKey(id); err != nil {
+				b.println("error deleting drill key", id, ":", err)
+			}
+		}
+	}()
+
+	err = d.step("keygen", func() error {
+		keyID, err = b.generateKey(a)
+		d.KeyID = keyID
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.step("public key", func() error {
+		publicKey, err = b.publicKey(a, keyID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.step("copy to new key ID", func() error {
+		newKeyID := random.String(20)
+		sessionConfig := test.CreateSessionConfig(b.clients)
+		err := b.runSession(b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+			var err error
+			if a.isECDSA() {
+				_, err = client.ECDSA().CopyKey(ctx, sessionConfig, keyID, "", b.threshold, newKeyID)
+			} else {
+				_, err = client.Schnorr().CopyKey(ctx, sessionConfig, keyID, "", b.threshold, newKeyID)
+			}
+			return err
+		})
+		if err == nil {
+			restoreKeyID = newKeyID
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var backups test.SessionResults[[]byte]
+	err = d.step("backup", func() error {
+		var err error
+		backups, err = runSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]byte, error) {
+			var backup []byte
+			var err error
+			if a.isECDSA() {
+				backup, err = client.ECDSA().BackupKeyShare(ctx, restoreKeyID)
+			} else {
+				backup, err = client.Schnorr().BackupKeyShare(ctx, restoreKeyID)
+			}
+			if err == nil && len(backup) == 0 {
+				err = fmt.Errorf("empty backup")
+			}
+			return backup, err
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.step("delete shares", func() error {
+		return b.deleteKey(restoreKeyID)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.step("restore", func() error {
+		return b.runSession(b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+			var restoredKeyID string
+			var err error
+			if a.isECDSA() {
+				restoredKeyID, err = client.ECDSA().RestoreKeyShare(ctx, backups[playerIndex].Result)
+			} else {
+				restoredKeyID, err = client.Schnorr().RestoreKeyShare(ctx, backups[playerIndex].Result)
+			}
+			if err != nil {
+				return err
+			}
+			if restoredKeyID != restoreKeyID {
+				return fmt.Errorf("restored key ID %s, expected %s", restoredKeyID, restoreKeyID)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.step("check public key", func() error {
+		restoredPublicKey, err := b.publicKey(a, restoreKeyID)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(restoredPublicKey, publicKey) {
+			return fmt.Errorf("public key of restored key does not match the original")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.step("sign and verify", func() error {
+		return b.signAndVerify(a, restoreKeyID, publicKey)
+	})
+}
@@ -0,0 +1,652 @@
+// Package bench is the engine of the benchmark command: it runs a load of MPC sessions against a cluster of TSM nodes
+// and measures the throughput, latency and failures. Configure a benchmark with Options, starting from DefaultOptions,
+// create it with New, and call Run, which returns the same Result that the benchmark command writes with -output.
+// Progress is reported to Options.OnEvent, and the report of the benchmark command to Options.Log. Preflight checks
+// that a cluster is ready for a benchmark, and Cleanup deletes the keys that benchmarks left on it. WriteReport and
+// WriteComparison render saved results.
+package bench
+
+import (
+	"benchmark/faults"
+	"benchmark/test"
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"golang.org/x/exp/rand"
+)
+
+// Options are the parameters of a benchmark. Start from DefaultOptions, which holds the defaults of the benchmark
+// command, and set at least the nodes and the clients, or a scenario file.
+type Options struct {
+
+	// General parameters
+	Nodes          map[int]*tsm.Configuration // The MPC nodes, by player index; the players are numbered from 0
+	Operation      string                     // One of: sign, presigGen, onlineSign, getpub, keygen, reshare, exportImport, bip32, backupDrill, recoveryDrill
+	Clients        []AlgorithmClients         // Each algorithm gets its own key and its own results
+	Threshold      int                        // Zero is the number of nodes - 1
+	Signers        int                        // Number of players in a signing session, chosen at random; zero is threshold + 1
+	Duration       time.Duration              // For how long the test runs; a scenario runs for the duration of its phases
+	ShowProgress   bool                       // Print a line for each session to Log
+	Delay          time.Duration              // Maximum random time each client sleeps between two sessions
+	SessionTimeout time.Duration              // Zero waits for the MPC nodes to time out
+
+	// Parameters used only for operations sign and onlineSign, not in scenarios
+	VerifyRate float64 // Fraction of the signatures that are combined and verified locally
+
+	// Parameters used only for operation reshare
+	ReshareThreshold  int // If set, the keys are copied to this threshold before the test, and the copies reshared
+	SignDuringReshare int // Number of clients per algorithm signing with the keys while they are being reshared
+
+	// Parameters used only for operation bip32
+	BIP32Path string // Such as m/44'/0'/0'; elements ending with ' are hardened
+
+	// Parameters used only for operation presigGen
+	PresigCount     int
+	PresigBatchSize uint64
+	PresigDir       string // Also read by operation onlineSign
+
+	// Mixed workload described in a JSON file; replaces the operation and the clients
+	ScenarioFile string
+
+	// Parameters used only in open-loop mode, which is enabled by setting the rate
+	Rate        float64 // Sessions per second per algorithm
+	Arrivals    string  // One of: fixed, poisson
+	MaxInFlight int
+
+	// Parameters used only in ramp mode, which is enabled by setting the ramp
+	Ramp          string // One of: steps, search
+	RampStart     float64
+	RampIncrement float64
+	RampMax       float64
+	SLOP99        time.Duration
+	SLOErrorRate  float64
+
+	// Control endpoint of a fault proxy in front of the nodes, used to label the results with the fault profiles
+	FaultProxy string
+
+	// If set, the IDs of the keys that the benchmark generates and leaves on the nodes are appended to this file, so
+	// that Cleanup can delete them
+	KeyFile string
+
+	// Receives the parameters, progress and results of the benchmark as printed by the benchmark command; nil
+	// discards them
+	Log io.Writer
+	// Called for each session and when each client stops; see Event
+	OnEvent func(Event)
+}
+
+// AlgorithmClients is the number of concurrent clients of an algorithm
+type AlgorithmClients struct {
+	Algorithm Algorithm
+	Clients   int
+}
+
+// DefaultOptions returns the options with the defaults of the benchmark command, without any nodes or clients
+func DefaultOptions() Options {
+	return Options{
+		Operation:       "sign",
+		Duration:        30 * time.Second,
+		BIP32Path:       "m/44'/0'/0'",
+		PresigCount:     100,
+		PresigBatchSize: 5,
+		PresigDir:       "./presigs",
+		Arrivals:        "fixed",
+		MaxInFlight:     100,
+		RampStart:       5,
+		RampIncrement:   5,
+		RampMax:         50,
+		SLOErrorRate:    0.01,
+	}
+}
+
+type Benchmark struct {
+
+	// General parameters
+	tsmConfigs     map[int]*tsm.Configuration
+	operation      string
+	threshold      int
+	signers        int
+	duration       time.Duration
+	showProgress   bool
+	delay          time.Duration
+	sessionTimeout time.Duration
+
+	// Parameters used only for operations sign and onlineSign
+	verifyRate float64
+
+	// Parameters used only for operation reshare
+	reshareThreshold  int
+	signDuringReshare int
+
+	// Parameters used only for operation bip32
+	bip32PathFlag string
+	bip32Path     []uint32
+
+	// Parameters used only for operation presigGen
+	presigCount     int
+	presigBatchSize uint64
+	presigDir       string
+
+	// Mixed workload; replaces operation and the client counts
+	scenarioFile string
+	scenario     *Scenario
+
+	// Parameters used only in open-loop mode
+	rate        float64
+	arrivals    string
+	maxInFlight int
+
+	// Parameters used only in ramp mode
+	ramp          string
+	rampStart     float64
+	rampIncrement float64
+	rampMax       float64
+	sloP99        time.Duration
+	sloErrorRate  float64
+
+	// Control endpoint of a fault proxy in front of the nodes, used to label the results with the fault profiles
+	faultProxy    string
+	faultProfiles []faults.ProfileChange
+
+	// Ledger of the keys left on the nodes
+	keyFile   string
+	keyLedger *os.File
+	keyLock   sync.Mutex
+
+	// Reporting
+	log     io.Writer
+	onEvent func(Event)
+
+	// Stopping the benchmark early
+	stop      context.Context
+	stopFunc  context.CancelFunc
+	stoppedAt int64 // Unix nanoseconds; zero until stopped
+
+	// Populated during benchmark
+	ctx            context.Context
+	operationStart time.Time
+	playerTimings  *playerTimings
+	clients        map[int]*tsm.Client
+	algorithms     []*algorithmState
+	keysGenerated  bool
+	rampSteps      []StepResult
+	kneeLoad       *float64
+	phaseResults   []PhaseResult
+
+	signDuringReshareResults []OperationResult
+	stepResults              []OperationResult
+	drillResults             []DrillResult
+	ersPrivateKey            *rsa.PrivateKey
+}
+
+// Returns a benchmark with the given options, checking only the nodes
+func newBenchmark(options Options) (*Benchmark, error) {
+	b := &Benchmark{
+		tsmConfigs:        options.Nodes,
+		operation:         options.Operation,
+		threshold:         options.Threshold,
+		signers:           options.Signers,
+		duration:          options.Duration,
+		showProgress:      options.ShowProgress,
+		delay:             options.Delay,
+		sessionTimeout:    options.SessionTimeout,
+		verifyRate:        options.VerifyRate,
+		reshareThreshold:  options.ReshareThreshold,
+		signDuringReshare: options.SignDuringReshare,
+		bip32PathFlag:     options.BIP32Path,
+		presigCount:       options.PresigCount,
+		presigBatchSize:   options.PresigBatchSize,
+		presigDir:         options.PresigDir,
+		scenarioFile:      options.ScenarioFile,
+		rate:              options.Rate,
+		arrivals:          options.Arrivals,
+		maxInFlight:       options.MaxInFlight,
+		ramp:              options.Ramp,
+		rampStart:         options.RampStart,
+		rampIncrement:     options.RampIncrement,
+		rampMax:           options.RampMax,
+		sloP99:            options.SLOP99,
+		sloErrorRate:      options.SLOErrorRate,
+		faultProxy:        options.FaultProxy,
+		log:               options.Log,
+		onEvent:           options.OnEvent,
+		keyFile:           options.KeyFile,
+		playerTimings:     newPlayerTimings(),
+	}
+	b.stop, b.stopFunc = context.WithCancel(context.Background())
+	if b.log == nil {
+		b.log = io.Discard
+	}
+
+	playerCount := len(b.tsmConfigs)
+	if playerCount < 2 {
+		return nil, fmt.Errorf("not enough players: %d", playerCount)
+	}
+	for i := 0; i < playerCount; i++ {
+		if b.tsmConfigs[i] == nil {
+			return nil, fmt.Errorf("missing MPC node for player %d", i)
+		}
+	}
+
+	return b, nil
+}
+
+// New returns a benchmark with the given options, after checking them. Unset thresholds and signers get their
+// defaults; everything else must be set, such as by starting from DefaultOptions.
+func New(options Options) (*Benchmark, error) {
+	b, err := newBenchmark(options)
+	if err != nil {
+		return nil, err
+	}
+	playerCount := len(b.tsmConfigs)
+
+	if b.threshold == 0 {
+		b.threshold = playerCount - 1
+	}
+	if b.threshold < 1 || b.threshold >= playerCount {
+		return nil, fmt.Errorf("invalid threshold: %d", b.threshold)
+	}
+
+	if b.signers == 0 {
+		b.signers = b.threshold + 1
+	}
+	if b.signers < b.threshold+1 || b.signers > playerCount {
+		return nil, fmt.Errorf("invalid signers: %d", b.signers)
+	}
+
+	for _, c := range options.Clients {
+		if _, err := ParseAlgorithm(c.Algorithm.String()); err != nil {
+			return nil, err
+		}
+		if c.Clients < 1 {
+			return nil, fmt.Errorf("invalid client count for %s: %d", c.Algorithm, c.Clients)
+		}
+		if slices.ContainsFunc(b.algorithms, func(other *algorithmState) bool { return other.Algorithm == c.Algorithm }) {
+			return nil, fmt.Errorf("clients given more than once for algorithm: %s", c.Algorithm)
+		}
+		b.algorithms = append(b.algorithms, &algorithmState{Algorithm: c.Algorithm, clients: c.Clients})
+	}
+
+	if b.scenarioFile != "" {
+		b.scenario, err = loadScenario(b.scenarioFile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scenario file %s: %w", b.scenarioFile, err)
+		}
+		if b.rate > 0 || b.ramp != "" {
+			return nil, fmt.Errorf("scenario cannot be combined with rate or ramp")
+		}
+		if b.verifyRate > 0 {
+			return nil, fmt.Errorf("verifyRate not supported with scenario")
+		}
+	} else if len(b.algorithms) == 0 {
+		return nil, fmt.Errorf("at least one client required")
+	}
+
+	if b.duration <= 0 {
+		return nil, fmt.Errorf("invalid duration: %v", b.duration)
+	}
+	if b.delay < 0 {
+		return nil, fmt.Errorf("invalid delay: %v", b.delay)
+	}
+	if b.sessionTimeout < 0 {
+		return nil, fmt.Errorf("invalid sessionTimeout: %v", b.sessionTimeout)
+	}
+
+	if b.verifyRate < 0 || b.verifyRate > 1 {
+		return nil, fmt.Errorf("invalid verifyRate: %v", b.verifyRate)
+	}
+
+	if b.reshareThreshold != 0 && (b.reshareThreshold < 1 || b.reshareThreshold >= playerCount) {
+		return nil, fmt.Errorf("invalid reshareThreshold: %d", b.reshareThreshold)
+	}
+	if b.signDuringReshare < 0 || (b.signDuringReshare > 0 && b.signers < max(b.threshold, b.reshareThreshold)+1) {
+		return nil, fmt.Errorf("invalid signDuringReshare: %d", b.signDuringReshare)
+	}
+
+	if b.operation == "bip32" {
+		b.bip32Path, err = parseBIP32Path(b.bip32PathFlag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bip32Path: %w", err)
+		}
+		if slices.ContainsFunc(b.algorithms, func(a *algorithmState) bool { return a.Algorithm != bip32Algorithm }) {
+			return nil, fmt.Errorf("operation bip32 only supports ECDSA/secp256k1 clients")
+		}
+	}
+
+	if b.operation == "presigGen" && (b.presigCount < 1 || b.presigBatchSize < 1) {
+		return nil, fmt.Errorf("invalid presignature count or batch size: %d, %d", b.presigCount, b.presigBatchSize)
+	}
+
+	if b.rate < 0 || (b.rate > 0 && b.operation != "sign" && b.operation != "getpub" && b.operation != "keygen") {
+		return nil, fmt.Errorf("invalid rate: %v", b.rate)
+	}
+	if b.arrivals != "fixed" && b.arrivals != "poisson" {
+		return nil, fmt.Errorf("invalid arrivals: %s", b.arrivals)
+	}
+	if b.maxInFlight < 1 {
+		return nil, fmt.Errorf("invalid maxInFlight: %d", b.maxInFlight)
+	}
+
+	if b.ramp != "" {
+		if b.ramp != "steps" && b.ramp != "search" {
+			return nil, fmt.Errorf("invalid ramp: %s", b.ramp)
+		}
+		if b.operation != "sign" && b.operation != "getpub" && b.operation != "keygen" {
+			return nil, fmt.Errorf("ramp not supported for operation: %s", b.operation)
+		}
+		isWhole := func(v float64) bool { return v == math.Trunc(v) }
+		if b.rampStart <= 0 || b.rampIncrement <= 0 || b.rampMax < b.rampStart ||
+			(b.rate == 0 && !(isWhole(b.rampStart) && isWhole(b.rampIncrement) && isWhole(b.rampMax))) {
+			return nil, fmt.Errorf("invalid ramp steps: %v %v %v", b.rampStart, b.rampIncrement, b.rampMax)
+		}
+		if b.sloErrorRate < 0 || b.sloErrorRate > 1 {
+			return nil, fmt.Errorf("invalid sloErrorRate: %v", b.sloErrorRate)
+		}
+	}
+
+	return b, nil
+}
+
+// Run runs the benchmark and returns its result. Cancelling ctx stops the benchmark like Stop, and also aborts the
+// sessions in flight. If the benchmark fails once it has started, or any drill failed, the result of the part that ran
+// is returned along with the error. A Benchmark can only be run once.
+func (b *Benchmark) Run(ctx context.Context) (*Result, error) {
+	b.ctx = ctx
+	defer context.AfterFunc(ctx, b.Stop)()
+
+	b.println("Running benchmark with the following parameters")
+	b.println()
+	if b.scenario != nil {
+		b.println("Scenario:        ", b.scenarioFile)
+		b.println("Phases:          ", len(b.scenario.Phases))
+		b.println("MPC nodes:       ", len(b.tsmConfigs))
+	} else {
+		b.println("Operation:       ", b.operation)
+		b.println("MPC nodes:       ", len(b.tsmConfigs))
+		for _, a := range b.algorithms {
+			b.printf("%-17s %d\n", a.String()+" clients:", a.clients)
+		}
+	}
+	b.println("Threshold:       ", b.threshold)
+	b.println("Signers:         ", b.signers)
+	b.println("Random delay:    ", b.delay)
+	if b.scenario != nil {
+		b.println("Test duration:   ", b.scenario.duration())
+	} else {
+		b.println("Test duration:   ", b.duration)
+	}
+	if b.sessionTimeout > 0 {
+		b.println("Session timeout: ", b.sessionTimeout)
+	}
+	if b.verifyRate > 0 {
+		b.println("Verify rate:     ", b.verifyRate)
+	}
+	if b.operation == "reshare" {
+		b.println("New threshold:   ", b.reshareThreshold)
+		b.println("Sign clients:    ", b.signDuringReshare)
+	}
+	if b.operation == "bip32" {
+		b.println("BIP32 path:      ", b.bip32PathFlag)
+	}
+	if b.operation == "presigGen" {
+		b.println("PresigCount:     ", b.presigCount)
+		b.println("PresigBatchSize: ", b.presigBatchSize)
+	}
+	if b.rate > 0 {
+		b.println("Arrival rate:    ", b.rate, "sessions/sec per algorithm")
+		b.println("Arrivals:        ", b.arrivals)
+		b.println("Max in flight:   ", b.maxInFlight)
+	}
+	if b.ramp != "" {
+		b.println("Ramp:            ", b.ramp, "of", b.rampDimension(), "from", b.rampStart, "to", b.rampMax, "by", b.rampIncrement)
+		b.println("SLO p99 latency: ", b.sloP99)
+		b.println("SLO error rate:  ", b.sloErrorRate)
+	}
+	b.println()
+
+	var err error
+	b.clients, err = test.CreateClients(b.tsmConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.keyFile != "" {
+		b.keyLedger, err = os.OpenFile(b.keyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("error opening key file: %w", err)
+		}
+		defer func() { _ = b.keyLedger.Close() }()
+	}
+
+	if b.faultProxy != "" {
+		if _, err := faults.FetchHistory(ctx, b.faultProxy); err != nil {
+			return nil, fmt.Errorf("error contacting fault proxy: %w", err)
+		}
+	}
+
+	startTime := time.Now()
+
+	var runErr error
+	switch {
+	case b.scenario != nil:
+		runErr = b.benchmarkScenario()
+	case b.ramp != "":
+		runErr = b.benchmarkRamp()
+	default:
+		runErr = b.runOperation()
+	}
+
+	endTime := time.Now()
+	if b.faultProxy != "" {
+		// The fault profiles are read even if the benchmark was cancelled, so that the results can be labelled
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		history, err := faults.FetchHistory(fetchCtx, b.faultProxy)
+		cancel()
+		if err != nil {
+			b.println("Error reading fault profiles:", err)
+		} else {
+			b.faultProfiles = faults.ActiveDuring(history, startTime, endTime)
+		}
+	}
+
+	result := b.result(startTime, endTime)
+	if runErr != nil {
+		result.Error = runErr.Error()
+	}
+	WriteReport(b.log, &result)
+	if runErr != nil {
+		return &result, fmt.Errorf("benchmark failed: %w", runErr)
+	}
+	if failed := b.failedDrills(); failed > 0 {
+		return &result, fmt.Errorf("%d of %d drills failed", failed, len(b.drillResults))
+	}
+
+	return &result, nil
+}
+
+// Runs the operation once with the current parameters
+func (b *Benchmark) runOperation() error {
+	b.operationStart = time.Now()
+	switch {
+	case b.rate > 0:
+		return b.benchmarkOpenLoop()
+	case clientOperations[b.operation] != nil:
+		newOperation := clientOperations[b.operation]
+		return b.runClients(func(a *algorithmState, client int) Operation { return newOperation(b, a, client) })
+	case b.operation == "reshare":
+		return b.benchmarkReshare()
+	case b.operation == "exportImport":
+		return b.benchmarkExportImport()
+	case b.operation == "bip32":
+		return b.benchmarkBIP32()
+	case drills[b.operation] != nil:
+		return b.benchmarkDrill()
+	default:
+		return fmt.Errorf("invalid operation: %s", b.operation)
+	}
+}
+
+// Stop makes the benchmark stop starting new sessions. The sessions in flight are completed, and the results are
+// reported for the time the benchmark actually ran.
+func (b *Benchmark) Stop() {
+	atomic.CompareAndSwapInt64(&b.stoppedAt, 0, time.Now().UnixNano())
+	b.stopFunc()
+}
+
+// Reports whether the benchmark was stopped before the end of the test duration
+func (b *Benchmark) stopped() bool {
+	return b.stop.Err() != nil
+}
+
+// Reports whether a client loop should stop starting new sessions
+func (b *Benchmark) done(endTime time.Time) bool {
+	return b.stopped() || time.Now().After(endTime)
+}
+
+// Sleeps until t, and reports whether t was reached without the benchmark being stopped
+func (b *Benchmark) sleepUntil(t time.Time) bool {
+	timer := time.NewTimer(time.Until(t))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-b.stop.Done():
+		return false
+	}
+}
+
+// Returns the time the current operation ran for: the test duration, or less if the benchmark was stopped early
+func (b *Benchmark) runDuration() time.Duration {
+	if stoppedAt := atomic.LoadInt64(&b.stoppedAt); stoppedAt != 0 {
+		if d := time.Unix(0, stoppedAt).Sub(b.operationStart); d > 0 && d < b.duration {
+			return d
+		}
+	}
+	return b.duration
+}
+
+// Generates one benchmark key per algorithm
+func (b *Benchmark) generateKeys() error {
+	if b.keysGenerated {
+		return nil
+	}
+
+	for _, a := range b.algorithms {
+		var err error
+		a.keyID, err = b.generateKey(a.Algorithm)
+		if err != nil {
+			return fmt.Errorf("error running keygen for %s: %w", a, err)
+		}
+		b.recordKey(a.keyID)
+		if err := b.checkKey(a.Algorithm, a.keyID); err != nil {
+			return fmt.Errorf("error checking key for %s: %w", a, err)
+		}
+	}
+
+	b.keysGenerated = true
+	return nil
+}
+
+// Formats fault profile changes as a list of profiles with the time, relative to start, from which they were active
+func formatFaultProfiles(changes []faults.ProfileChange, start time.Time) string {
+	var profiles []string
+	for _, change := range changes {
+		profiles = append(profiles, fmt.Sprintf("%s@%v", change.Profile, max(change.Time.Sub(start), 0).Round(time.Second)))
+	}
+	return strings.Join(profiles, ";")
+}
+
+// The presignature IDs generated by a presigGen client, read by the onlineSign client with the same index. The files of
+// ECDSA/secp256k1 and Schnorr/Ed25519 keep the key ID field of the files from before other algorithms were supported,
+// ECDSAKeyID or Ed25519KeyID, so that the files of either version can be read by the other.
+type PresigIDs struct {
+	Algorithm    string `json:",omitempty"`
+	KeyID        string `json:",omitempty"`
+	ECDSAKeyID   string `json:",omitempty"`
+	Ed25519KeyID string `json:",omitempty"`
+	PresigIDs    []string
+}
+
+func newPresigIDs(a Algorithm, keyID string, presigIDs []string) PresigIDs {
+	switch a {
+	case legacyECDSA:
+		return PresigIDs{ECDSAKeyID: keyID, PresigIDs: presigIDs}
+	case legacyEd25519:
+		return PresigIDs{Ed25519KeyID: keyID, PresigIDs: presigIDs}
+	default:
+		return PresigIDs{Algorithm: a.String(), KeyID: keyID, PresigIDs: presigIDs}
+	}
+}
+
+// Returns the ID of the key of the presignatures, whichever version wrote the file
+func (p PresigIDs) keyID() string {
+	switch {
+	case p.KeyID != "":
+		return p.KeyID
+	case p.ECDSAKeyID != "":
+		return p.ECDSAKeyID
+	default:
+		return p.Ed25519KeyID
+	}
+}
+
+// The algorithms of the -ecdsaClients and -ed25519Clients flags, whose presignature files keep their original names
+var (
+	legacyECDSA   = Algorithm{"ECDSA", "secp256k1"}
+	legacyEd25519 = Algorithm{"Schnorr", tsm.SchnorrEd25519}
+)
+
+func (b *Benchmark) presigFilePath(a Algorithm, client int) string {
+	name := a.fileName()
+	switch a {
+	case legacyECDSA:
+		name = "ecdsa"
+	case legacyEd25519:
+		name = "ed25519"
+	}
+	return filepath.Join(b.presigDir, fmt.Sprintf("presig-%s-client%04d.txt", name, client))
+}
+
+// Returns a random subset of clients, along with a session configuration for these clients
+func subset(clients map[int]*tsm.Client, size int) (*tsm.SessionConfig, map[int]*tsm.Client) {
+	clientsSubset := make(map[int]*tsm.Client, size)
+
+	i := 0
+	players := make([]int, len(clients))
+	for p := range clients {
+		players[i] = p
+		i++
+	}
+
+	rand.Shuffle(len(players), func(i, j int) { players[i], players[j] = players[j], players[i] })
+	selected := players[:size]
+	for _, p := range selected {
+		clientsSubset[p] = clients[p]
+	}
+
+	sort.Ints(selected)
+	sessionConfig := tsm.NewSessionConfig(tsm.GenerateSessionID(), selected, nil)
+	return sessionConfig, clientsSubset
+}
+
+// Prints to the log of the benchmark, like fmt.Println
+func (b *Benchmark) println(a ...any) {
+	_, _ = fmt.Fprintln(b.log, a...)
+}
+
+// Prints to the log of the benchmark, like fmt.Printf
+func (b *Benchmark) printf(format string, a ...any) {
+	_, _ = fmt.Fprintf(b.log, format, a...)
+}
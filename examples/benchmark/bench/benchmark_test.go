@@ -0,0 +1,132 @@
+package bench
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Returns a benchmark with the default options that is not connected to any node, for testing the parts of the
+// benchmark that do not run sessions
+func newTestBenchmark(t *testing.T, presigDir string) *Benchmark {
+	t.Helper()
+	options := DefaultOptions()
+	options.Nodes = map[int]*tsm.Configuration{}
+	for i := 0; i < 3; i++ {
+		options.Nodes[i] = &tsm.Configuration{URL: "http://localhost:8080"}
+	}
+	options.Clients = []AlgorithmClients{{Algorithm{"ECDSA", "secp256k1"}, 1}}
+	options.PresigDir = presigDir
+	b, err := New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestSubset(t *testing.T) {
+	clients := map[int]*tsm.Client{}
+	for i := 0; i < 5; i++ {
+		clients[i] = &tsm.Client{}
+	}
+	tests := []int{1, 3, 5}
+	for _, size := range tests {
+		selected := map[int]bool{}
+		for i := 0; i < 200; i++ {
+			sessionConfig, clientsSubset := subset(clients, size)
+			if sessionConfig == nil || sessionConfig.SessionID() == "" {
+				t.Fatalf("subset of %d: no session config", size)
+			}
+			if len(clientsSubset) != size {
+				t.Fatalf("subset of %d has %d clients", size, len(clientsSubset))
+			}
+			for player, client := range clientsSubset {
+				if clients[player] != client {
+					t.Fatalf("subset of %d has client %p for player %d, want %p", size, client, player, clients[player])
+				}
+				selected[player] = true
+			}
+		}
+		// The subsets are random, so every player is eventually selected
+		if len(selected) != len(clients) {
+			t.Errorf("subsets of %d only selected players %v", size, selected)
+		}
+	}
+}
+
+// Presignature files are written by presigGen and read by onlineSign and cleanup. The files of ECDSA/secp256k1 and
+// Schnorr/Ed25519 keep the names and key ID fields of the files from before other algorithms were supported.
+func TestPresigFileRoundTrip(t *testing.T) {
+	tests := []struct {
+		algorithm  Algorithm
+		file       string
+		keyIDField string
+	}{
+		{Algorithm{"ECDSA", "secp256k1"}, "presig-ecdsa-client0001.txt", "ECDSAKeyID"},
+		{Algorithm{"Schnorr", tsm.SchnorrEd25519}, "presig-ed25519-client0001.txt", "Ed25519KeyID"},
+		{Algorithm{"ECDSA", "P-256"}, "presig-ecdsa-p-256-client0001.txt", "KeyID"},
+		{Algorithm{"Schnorr", tsm.SchnorrBIP340}, "presig-schnorr-bip-340-client0001.txt", "KeyID"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		b := newTestBenchmark(t, dir)
+		a := &algorithmState{Algorithm: tt.algorithm, keyID: "key-" + tt.algorithm.fileName()}
+		presigIDs := []string{"presig0", "presig1", "presig2"}
+
+		gen := b.newPresigGenClient(a, 1).(*presigGenClient)
+		gen.presigIDs = presigIDs
+		if err := gen.Teardown(); err != nil {
+			t.Fatalf("%v: writing presig file: %v", tt.algorithm, err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatalf("%v: %v", tt.algorithm, err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("%v: %v", tt.algorithm, err)
+		}
+		if fields[tt.keyIDField] != a.keyID {
+			t.Errorf("%v: presig file %s has no %s %s", tt.algorithm, data, tt.keyIDField, a.keyID)
+		}
+
+		online := b.newOnlineSignClient(a, 1).(*onlineSignClient)
+		if err := online.Setup(); err != nil {
+			t.Fatalf("%v: reading presig file: %v", tt.algorithm, err)
+		}
+		if online.presigs.keyID() != a.keyID || !slices.Equal(online.presigs.PresigIDs, presigIDs) {
+			t.Errorf("%v: read key %s and presignatures %v, want key %s and presignatures %v", tt.algorithm, online.presigs.keyID(), online.presigs.PresigIDs, a.keyID, presigIDs)
+		}
+	}
+}
+
+// Presignature files written before other algorithms were supported can still be read
+func TestReadLegacyPresigFile(t *testing.T) {
+	tests := []struct {
+		algorithm Algorithm
+		file      string
+		content   string
+	}{
+		{Algorithm{"ECDSA", "secp256k1"}, "presig-ecdsa-client0000.txt", `{"ECDSAKeyID": "key", "PresigIDs": ["a", "b"]}`},
+		{Algorithm{"Schnorr", tsm.SchnorrEd25519}, "presig-ed25519-client0000.txt", `{"Ed25519KeyID": "key", "PresigIDs": ["a", "b"]}`},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		b := newTestBenchmark(t, dir)
+		online := b.newOnlineSignClient(&algorithmState{Algorithm: tt.algorithm}, 0).(*onlineSignClient)
+		if err := online.Setup(); err != nil {
+			t.Fatalf("%v: %v", tt.algorithm, err)
+		}
+		if online.presigs.keyID() != "key" || !slices.Equal(online.presigs.PresigIDs, []string{"a", "b"}) {
+			t.Errorf("%v: read key %s and presignatures %v", tt.algorithm, online.presigs.keyID(), online.presigs.PresigIDs)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// BIP32 keys are ECDSA keys on secp256k1
+var bip32Algorithm = Algorithm{"ECDSA", "secp256k1"}
+
+// Derivation path elements at or above this are hardened
+const bip32Hardened = 1 << 31
+
+// The steps of a BIP32 derivation chain that are counted on their own. deriveFromKey is counted once per element of
+// the derivation path.
+var bip32Steps = []string{"generateSeed", "deriveFromSeed", "deriveFromKey", "convertKey", "info", "sign"}
+
+// Parses a derivation path such as m/44'/0'/0'. Elements ending with ' or h are hardened.
+func parseBIP32Path(path string) ([]uint32, error) {
+	elements := strings.Split(path, "/")
+	if elements[0] == "m" {
+		elements = elements[1:]
+	}
+	var derivationPath []uint32
+	for _, e := range elements {
+		hardened := strings.HasSuffix(e, "'") || strings.HasSuffix(e, "h")
+		if hardened {
+			e = e[:len(e)-1]
+		}
+		v, err := strconv.ParseUint(e, 10, 32)
+		if err != nil || v >= bip32Hardened {
+			return nil, fmt.Errorf("invalid derivation path element: %s", e)
+		}
+		if hardened {
+			v += bip32Hardened
+		}
+		derivationPath = append(derivationPath, uint32(v))
+	}
+	return derivationPath, nil
+}
+
+// Each ECDSA client repeatedly generates a BIP32 seed, derives the master key from the seed and the child keys along
+// bip32Path from the master key, converts the last child key to an ECDSA key and signs with it. A chain is only
+// counted as an operation if all steps succeed; the steps are also counted on their own.
+func (b *Benchmark) benchmarkBIP32() error {
+	counters := map[*algorithmState][]stepCounters{}
+	err := b.runClients(func(a *algorithmState, client int) Operation {
+		c := &bip32Client{operationClient: operationClient{b, a, client}, counters: newStepCounters(bip32Steps)}
+		counters[a] = append(counters[a], c.counters)
+		return c
+	})
+	for _, a := range b.algorithms {
+		b.stepResults = append(b.stepResults, b.mergeStepCounters(a.String(), bip32Steps, counters[a])...)
+	}
+	return err
+}
+
+// Runs BIP32 derivation chains, and counts their steps in its own counters
+type bip32Client struct {
+	operationClient
+	counters stepCounters
+}
+
+func (c *bip32Client) Run() (func() error, error) {
+	return nil, c.b.bip32Chain(c.counters)
+}
+
+func (b *Benchmark) bip32Chain(counters stepCounters) error {
+	// All seeds and keys of the chain are deleted when done
+	var keyIDs []string
+	defer func() {
+		for _, keyID := range keyIDs {
+			if err := b.deleteKey(keyID); err != nil {
+				b.println("error deleting BIP32 key", keyID, ":", err)
+			}
+		}
+	}()
+
+	var seedID string
+	err := timeOperation(counters["generateSeed"], func() error {
+		var err error
+		seedID, err = b.runForKeyID(func(ctx context.Context, client *tsm.Client, sessionConfig *tsm.SessionConfig) (string, error) {
+			return client.ECDSA().BIP32GenerateSeed(ctx, sessionConfig, b.threshold)
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("generate seed: %w", err)
+	}
+	keyIDs = append(keyIDs, seedID)
+
+	var parentKeyID string
+	err = timeOperation(counters["deriveFromSeed"], func() error {
+		var err error
+		parentKeyID, err = b.runForKeyID(func(ctx context.Context, client *tsm.Client, sessionConfig *tsm.SessionConfig) (string, error) {
+			return client.ECDSA().BIP32DeriveFromSeed(ctx, sessionConfig, seedID)
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("derive from seed: %w", err)
+	}
+	keyIDs = append(keyIDs, parentKeyID)
+
+	for _, element := range b.bip32Path {
+		var childKeyID string
+		err = timeOperation(counters["deriveFromKey"], func() error {
+			var err error
+			childKeyID, err = b.runForKeyID(func(ctx context.Context, client *tsm.Client, sessionConfig *tsm.SessionConfig) (string, error) {
+				return client.ECDSA().BIP32DeriveFromKey(ctx, sessionConfig, parentKeyID, element)
+			})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("derive from key: %w", err)
+		}
+		keyIDs = append(keyIDs, childKeyID)
+		parentKeyID = childKeyID
+	}
+
+	var keyID string
+	err = timeOperation(counters["convertKey"], func() error {
+		var err error
+		keyID, err = b.runForKeyID(func(ctx context.Context, client *tsm.Client, sessionConfig *tsm.SessionConfig) (string, error) {
+			return client.ECDSA().BIP32ConvertKey(ctx, sessionConfig, parentKeyID)
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("convert key: %w", err)
+	}
+	keyIDs = append(keyIDs, keyID)
+
+	// Checks that every player derived the last BIP32 key along the derivation path
+	err = timeOperation(counters["info"], func() error {
+		return b.runSession(b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+			info, err := client.ECDSA().BIP32Info(ctx, parentKeyID)
+			if err != nil {
+				return err
+			}
+			if info.KeyType != "BIP32Key" || !slices.Equal(info.DerivationPath, b.bip32Path) {
+				return fmt.Errorf("unexpected BIP32 key info: type %s, derivation path %v", info.KeyType, info.DerivationPath)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("info: %w", err)
+	}
+
+	publicKey, err := b.publicKey(bip32Algorithm, keyID)
+	if err != nil {
+		return err
+	}
+	err = timeOperation(counters["sign"], func() error {
+		return b.signAndVerify(bip32Algorithm, keyID, publicKey)
+	})
+	if err != nil {
+		return fmt.Errorf("sign: %w", err)
+	}
+	return nil
+}
+
+// Runs a session that creates a key or seed on all players, and returns its ID after checking that all players agree
+// on it
+func (b *Benchmark) runForKeyID(f func(ctx context.Context, client *tsm.Client, sessionConfig *tsm.SessionConfig) (string, error)) (string, error) {
+	sessionConfig := test.CreateSessionConfig(b.clients)
+	keyIDs, err := runSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) (string, error) {
+		return f(ctx, client, sessionConfig)
+	})
+	if err != nil {
+		return "", err
+	}
+	return keyIDs.AllEqual("key ID", sessionConfig.SessionID(), func(a, b string) bool { return a == b })
+}
@@ -0,0 +1,42 @@
+package bench
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseBIP32Path(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []uint32
+		wantErr bool
+	}{
+		{in: "m/44'/0'/0'", want: []uint32{44 + bip32Hardened, bip32Hardened, bip32Hardened}},
+		{in: "m/44h/60h/0h/0/7", want: []uint32{44 + bip32Hardened, 60 + bip32Hardened, bip32Hardened, 0, 7}},
+		{in: "0/1", want: []uint32{0, 1}},
+		{in: "m/2147483647", want: []uint32{bip32Hardened - 1}},
+		{in: "m/2147483647'", want: []uint32{bip32Hardened - 1 + bip32Hardened}},
+		{in: "m/2147483648", wantErr: true},
+		{in: "m/-1", wantErr: true},
+		{in: "m/x", wantErr: true},
+		{in: "m/'", wantErr: true},
+		{in: "m//1", wantErr: true},
+		{in: "m/1/", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseBIP32Path(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseBIP32Path(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseBIP32Path(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseBIP32Path(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
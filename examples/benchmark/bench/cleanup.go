@@ -0,0 +1,147 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Appends the ID of a key that is left on the nodes to the key file, if any
+func (b *Benchmark) recordKey(keyID string) {
+	if b.keyLedger == nil {
+		return
+	}
+	b.keyLock.Lock()
+	defer b.keyLock.Unlock()
+	if _, err := fmt.Fprintln(b.keyLedger, keyID); err != nil {
+		b.println("error recording key", keyID, "in key file:", err)
+	}
+}
+
+// Cleanup deletes the keys recorded in Options.KeyFile by earlier benchmarks, and the presignatures listed in the
+// presignature files in Options.PresigDir. Only the nodes, the key file, the presignature directory and the log of the
+// options are used. The presignature files are removed once their presignatures are deleted, and the key file is
+// rewritten with the keys that could not be deleted, so that Cleanup can be run again. If dryRun is set, Cleanup only
+// lists what it would delete.
+func Cleanup(ctx context.Context, options Options, dryRun bool) error {
+	b, err := newBenchmark(options)
+	if err != nil {
+		return err
+	}
+	b.ctx = ctx
+
+	keyIDs, err := readKeyFile(b.keyFile)
+	if err != nil {
+		return fmt.Errorf("error reading key file: %w", err)
+	}
+	presigFiles, err := filepath.Glob(filepath.Join(b.presigDir, "presig-*.txt"))
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		for _, path := range presigFiles {
+			b.println("Would delete the presignatures listed in", path)
+		}
+		for _, keyID := range keyIDs {
+			b.println("Would delete key", keyID)
+		}
+		b.printf("%d keys and %d presignature files to delete\n", len(keyIDs), len(presigFiles))
+		return nil
+	}
+
+	b.clients, err = test.CreateClients(b.tsmConfigs)
+	if err != nil {
+		return err
+	}
+
+	var failedPresigFiles int
+	for _, path := range presigFiles {
+		if err := b.deletePresignatures(path); err != nil {
+			b.println("Error deleting the presignatures listed in", path, ":", err)
+			failedPresigFiles++
+			continue
+		}
+		b.println("Deleted the presignatures listed in", path)
+	}
+
+	var failedKeyIDs []string
+	for _, keyID := range keyIDs {
+		if err := b.deleteKey(keyID); err != nil {
+			b.println("Error deleting key", keyID, ":", err)
+			failedKeyIDs = append(failedKeyIDs, keyID)
+			continue
+		}
+		if b.showProgress {
+			b.println("Deleted key", keyID)
+		}
+	}
+
+	if b.keyFile != "" {
+		if len(failedKeyIDs) == 0 {
+			err = os.Remove(b.keyFile)
+		} else {
+			err = os.WriteFile(b.keyFile, []byte(strings.Join(failedKeyIDs, "\n")+"\n"), 0644)
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error updating key file: %w", err)
+		}
+	}
+
+	b.printf("Deleted %d of %d keys and the presignatures of %d of %d presignature files\n", len(keyIDs)-len(failedKeyIDs), len(keyIDs), len(presigFiles)-failedPresigFiles, len(presigFiles))
+	if len(failedKeyIDs) > 0 || failedPresigFiles > 0 {
+		return fmt.Errorf("cleanup failed for %d keys and %d presignature files", len(failedKeyIDs), failedPresigFiles)
+	}
+	return nil
+}
+
+// Deletes the presignatures of the key of a presignature file on all players, and removes the file
+func (b *Benchmark) deletePresignatures(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var presigs PresigIDs
+	if err := json.Unmarshal(data, &presigs); err != nil {
+		return err
+	}
+	err = b.runSetupSession(b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+		return client.KeyManagement().DeletePresignatures(ctx, presigs.keyID())
+	})
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// Returns the distinct key IDs of a key file, in the order they were recorded. A missing file has no keys.
+func readKeyFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keyIDs []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(string(data), "\n") {
+		keyID := strings.TrimSpace(line)
+		if keyID != "" && !seen[keyID] {
+			keyIDs = append(keyIDs, keyID)
+			seen[keyID] = true
+		}
+	}
+	return keyIDs, nil
+}
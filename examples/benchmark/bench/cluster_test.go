@@ -0,0 +1,214 @@
+package bench
+
+import (
+	"benchmark/mock"
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// The algorithms run against the mock cluster: the mock nodes support ECDSA on all curves and Schnorr with Ed25519.
+// Ed25519 is given in its short form, as with -ed25519Clients.
+var clusterTestAlgorithms = []string{"ECDSA", "ECDSA/P-256", "Ed25519"}
+
+// Starts a cluster of three mock nodes for the duration of the test, and returns the options to run a short benchmark
+// against it with one client per algorithm
+func clusterTestOptions(t *testing.T) Options {
+	t.Helper()
+	cluster := mock.StartCluster(3, mock.Config{})
+	t.Cleanup(cluster.Close)
+
+	options := DefaultOptions()
+	options.Nodes = map[int]*tsm.Configuration{}
+	for i, nodeURL := range cluster.NodeURLs() {
+		u, err := url.Parse(nodeURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apiKey := u.User.Username()
+		u.User = nil
+		options.Nodes[i] = (&tsm.Configuration{URL: u.String()}).WithAPIKeyAuthentication(apiKey)
+	}
+	for _, name := range clusterTestAlgorithms {
+		a, err := ParseAlgorithm(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		options.Clients = append(options.Clients, AlgorithmClients{Algorithm: a, Clients: 1})
+	}
+	options.Threshold = 1
+	options.Duration = 300 * time.Millisecond
+	options.PresigDir = t.TempDir()
+	options.KeyFile = filepath.Join(t.TempDir(), "keys.txt")
+	return options
+}
+
+func runClusterTest(t *testing.T, options Options) *Result {
+	t.Helper()
+	b, err := New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := b.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// Checks that every algorithm completed sessions, and that none failed
+func checkAlgorithmResults(t *testing.T, r *Result) {
+	t.Helper()
+	if len(r.Algorithms) != len(clusterTestAlgorithms) {
+		t.Fatalf("%d algorithm results, want %d", len(r.Algorithms), len(clusterTestAlgorithms))
+	}
+	for _, a := range r.Algorithms {
+		if a.Operations == 0 || a.Errors != 0 || a.InvalidSignatures != 0 || a.Inconsistent != 0 {
+			t.Errorf("%s: %d operations, %d errors, %d invalid signatures, %d inconsistent; failures %+v", a.Algorithm, a.Operations, a.Errors, a.InvalidSignatures, a.Inconsistent, a.Failures)
+		}
+	}
+}
+
+func TestClusterSign(t *testing.T) {
+	options := clusterTestOptions(t)
+	options.VerifyRate = 1
+	checkAlgorithmResults(t, runClusterTest(t, options))
+}
+
+func TestClusterKeygen(t *testing.T) {
+	options := clusterTestOptions(t)
+	options.Operation = "keygen"
+	checkAlgorithmResults(t, runClusterTest(t, options))
+}
+
+func TestClusterPresignatures(t *testing.T) {
+	options := clusterTestOptions(t)
+	options.Operation = "presigGen"
+	options.PresigCount = 10
+	options.Duration = 10 * time.Second
+	checkAlgorithmResults(t, runClusterTest(t, options))
+
+	options.Operation = "onlineSign"
+	options.VerifyRate = 1
+	r := runClusterTest(t, options)
+	checkAlgorithmResults(t, r)
+	for _, a := range r.Algorithms {
+		if a.Operations != uint64(options.PresigCount) {
+			t.Errorf("%s: signed with %d presignatures, want %d", a.Algorithm, a.Operations, options.PresigCount)
+		}
+	}
+}
+
+func TestClusterReshare(t *testing.T) {
+	for _, reshareThreshold := range []int{0, 2} {
+		options := clusterTestOptions(t)
+		options.Operation = "reshare"
+		options.ReshareThreshold = reshareThreshold
+		options.Signers = 3
+		options.SignDuringReshare = 1
+		r := runClusterTest(t, options)
+		checkAlgorithmResults(t, r)
+		for _, op := range r.SignDuringReshare {
+			if op.Errors != 0 {
+				t.Errorf("reshareThreshold %d: %s: %d failed signatures during reshare; failures %+v", reshareThreshold, op.Algorithm, op.Errors, op.Failures)
+			}
+		}
+
+		// The keys generated with the threshold of the benchmark are deleted after they are copied
+		keyIDs, err := readKeyFile(options.KeyFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keyIDs) != len(clusterTestAlgorithms) {
+			t.Errorf("reshareThreshold %d: %d keys left on the nodes, want %d", reshareThreshold, len(keyIDs), len(clusterTestAlgorithms))
+		}
+	}
+}
+
+// Runs the example scenarios, with their phases shortened
+func TestClusterScenarios(t *testing.T) {
+	files, err := filepath.Glob("../scenarios/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no example scenarios")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			scenario, err := loadScenario(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range scenario.Phases {
+				scenario.Phases[i].Duration = scenarioDuration(300 * time.Millisecond)
+			}
+			data, err := json.Marshal(scenario)
+			if err != nil {
+				t.Fatal(err)
+			}
+			options := clusterTestOptions(t)
+			options.Clients = nil
+			options.ScenarioFile = filepath.Join(t.TempDir(), filepath.Base(file))
+			if err := os.WriteFile(options.ScenarioFile, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			r := runClusterTest(t, options)
+			if r.Scenario == nil || len(r.Scenario.Phases) != len(scenario.Phases) {
+				t.Fatalf("scenario result %+v, want %d phases", r.Scenario, len(scenario.Phases))
+			}
+			for _, phase := range r.Scenario.Phases {
+				var operations uint64
+				for _, op := range phase.Operations {
+					operations += op.Operations
+					if op.Errors != 0 {
+						t.Errorf("phase %s: %d failed %s sessions on key pool %s; failures %+v", phase.Name, op.Errors, op.Operation, op.KeyPool, op.Failures)
+					}
+				}
+				if operations == 0 {
+					t.Errorf("phase %s: no operations", phase.Name)
+				}
+			}
+		})
+	}
+}
+
+// onlineSign operations on a key pool without presignatures are reported apart from the failed sessions
+func TestClusterScenarioPresignatures(t *testing.T) {
+	scenario := `{
+  "keyPools": [{"name": "wallets", "algorithm": "ECDSA", "size": 1}],
+  "phases": [
+    {"name": "empty", "duration": "300ms", "clients": 1, "operations": [{"operation": "onlineSign", "keyPool": "wallets", "weight": 1}]},
+    {"name": "mixed", "duration": "300ms", "clients": 2, "operations": [
+      {"operation": "presigGen", "keyPool": "wallets", "weight": 1, "presigBatchSize": 2},
+      {"operation": "onlineSign", "keyPool": "wallets", "weight": 1}
+    ]}
+  ]
+}`
+	options := clusterTestOptions(t)
+	options.Clients = nil
+	options.ScenarioFile = filepath.Join(t.TempDir(), "presignatures.json")
+	if err := os.WriteFile(options.ScenarioFile, []byte(scenario), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := runClusterTest(t, options)
+	if r.Scenario == nil || len(r.Scenario.Phases) != 2 {
+		t.Fatalf("scenario result %+v, want 2 phases", r.Scenario)
+	}
+	empty := r.Scenario.Phases[0].Operations[0]
+	if empty.Operations != 0 || empty.Errors != 0 || empty.Exhausted == 0 {
+		t.Errorf("onlineSign without presignatures: %d operations, %d errors, %d exhausted", empty.Operations, empty.Errors, empty.Exhausted)
+	}
+	mixed := r.Scenario.Phases[1].Operations[1]
+	if mixed.Operations == 0 || mixed.Errors != 0 {
+		t.Errorf("onlineSign with presignatures: %d operations, %d errors; failures %+v", mixed.Operations, mixed.Errors, mixed.Failures)
+	}
+}
@@ -0,0 +1,192 @@
+package bench
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// The metrics of one line of a result that are compared between two results
+type comparedMetrics struct {
+	opsPerSecond float64
+	errorRate    float64
+	latency      *LatencySummary
+}
+
+// WriteComparison writes how a candidate result differs from a base result: first the parameters that differ, then the
+// throughput, error rate and latency of each algorithm, operation, scenario phase and ramp step found in both results.
+// Lines found in only one of the results are listed at the end.
+func WriteComparison(w io.Writer, base, candidate *Result) {
+	p := reportWriter{w}
+
+	differences := compareParameters(base, candidate)
+	if len(differences) == 0 {
+		p.println("Parameters: same")
+	} else {
+		p.println("Parameters that differ:")
+		t := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(t, "\tBase\tCandidate")
+		for _, d := range differences {
+			_, _ = fmt.Fprintf(t, "%s\t%s\t%s\n", d[0], d[1], d[2])
+		}
+		_ = t.Flush()
+	}
+	if base.Stopped || candidate.Stopped {
+		p.println("At least one of the benchmarks was stopped early; its rates are for the time it ran")
+	}
+	if base.Error != "" || candidate.Error != "" {
+		p.println("At least one of the benchmarks failed; its results are partial")
+	}
+
+	baseLines, baseNames := comparedLines(base)
+	candidateLines, candidateNames := comparedLines(candidate)
+
+	p.println()
+	t := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(t, "\t\tBase\tCandidate\tChange")
+	var onlyInBase, onlyInCandidate []string
+	for _, name := range baseNames {
+		c, found := candidateLines[name]
+		if !found {
+			onlyInBase = append(onlyInBase, name)
+			continue
+		}
+		b := baseLines[name]
+		_, _ = fmt.Fprintf(t, "%s\tops/sec\t%.2f\t%.2f\t%s\n", name, b.opsPerSecond, c.opsPerSecond, relativeChange(b.opsPerSecond, c.opsPerSecond))
+		_, _ = fmt.Fprintf(t, "\terror rate\t%.2f%%\t%.2f%%\t%+.2f points\n", 100*b.errorRate, 100*c.errorRate, 100*(c.errorRate-b.errorRate))
+		if b.latency != nil && c.latency != nil {
+			_, _ = fmt.Fprintf(t, "\tp50\t%v\t%v\t%s\n", fromMilliseconds(b.latency.P50), fromMilliseconds(c.latency.P50), relativeChange(b.latency.P50, c.latency.P50))
+			_, _ = fmt.Fprintf(t, "\tp99\t%v\t%v\t%s\n", fromMilliseconds(b.latency.P99), fromMilliseconds(c.latency.P99), relativeChange(b.latency.P99, c.latency.P99))
+		}
+	}
+	_ = t.Flush()
+	for _, name := range candidateNames {
+		if _, found := baseLines[name]; !found {
+			onlyInCandidate = append(onlyInCandidate, name)
+		}
+	}
+	if len(onlyInBase) > 0 {
+		p.println("Only in base:", strings.Join(onlyInBase, ", "))
+	}
+	if len(onlyInCandidate) > 0 {
+		p.println("Only in candidate:", strings.Join(onlyInCandidate, ", "))
+	}
+
+	if base.Ramp != nil && candidate.Ramp != nil {
+		p.printf("Highest load within SLO: %s -> %s\n", formatKnee(base.Ramp), formatKnee(candidate.Ramp))
+	}
+	if len(base.Drills) > 0 || len(candidate.Drills) > 0 {
+		p.printf("Drills passed: %s -> %s\n", formatDrillsPassed(base.Drills), formatDrillsPassed(candidate.Drills))
+	}
+}
+
+// Returns the name, base value and candidate value of each parameter that differs between two results
+func compareParameters(base, candidate *Result) [][3]string {
+	b, c := base.Parameters, candidate.Parameters
+	parameters := [][3]string{
+		{"Operation", b.Operation, c.Operation},
+		{"MPC nodes", fmt.Sprint(len(b.Nodes)), fmt.Sprint(len(c.Nodes))},
+		{"Clients", formatClients(b.Clients), formatClients(c.Clients)},
+		{"Threshold", fmt.Sprint(b.Threshold), fmt.Sprint(c.Threshold)},
+		{"Signers", fmt.Sprint(b.Signers), fmt.Sprint(c.Signers)},
+		{"Test duration", base.TestDuration().String(), candidate.TestDuration().String()},
+		{"Random delay", seconds(b.DelaySeconds).String(), seconds(c.DelaySeconds).String()},
+		{"Session timeout", seconds(b.SessionTimeout).String(), seconds(c.SessionTimeout).String()},
+		{"Verify rate", fmt.Sprint(b.VerifyRate), fmt.Sprint(c.VerifyRate)},
+		{"PresigBatchSize", fmt.Sprint(b.PresigBatchSize), fmt.Sprint(c.PresigBatchSize)},
+		{"Arrival rate", fmt.Sprint(b.Rate), fmt.Sprint(c.Rate)},
+		{"Arrivals", b.Arrivals, c.Arrivals},
+		{"Fault profiles", formatFaultProfiles(base.FaultProfiles, base.StartTime), formatFaultProfiles(candidate.FaultProfiles, candidate.StartTime)},
+	}
+	var differences [][3]string
+	for _, parameter := range parameters {
+		if parameter[1] != parameter[2] {
+			differences = append(differences, parameter)
+		}
+	}
+	return differences
+}
+
+// Returns the compared metrics of each line of a result by name, along with the names in the order of the result
+func comparedLines(r *Result) (map[string]comparedMetrics, []string) {
+	lines := map[string]comparedMetrics{}
+	var names []string
+	add := func(name string, m comparedMetrics) {
+		if _, found := lines[name]; !found {
+			names = append(names, name)
+		}
+		lines[name] = m
+	}
+	for _, a := range r.Algorithms {
+		add(a.Algorithm, comparedMetrics{a.OpsPerSecond, errorRate(a), a.Latency})
+	}
+	for _, op := range r.SignDuringReshare {
+		add(op.Algorithm+" sign during reshare", comparedMetrics{op.OpsPerSecond, op.ErrorRate, op.Latency})
+	}
+	for _, op := range r.Steps {
+		add(op.Algorithm+" "+op.Operation, comparedMetrics{op.OpsPerSecond, op.ErrorRate, op.Latency})
+	}
+	if r.Scenario != nil {
+		for _, phase := range r.Scenario.Phases {
+			for _, op := range phase.Operations {
+				name := fmt.Sprintf("%s %s %s", phase.Name, op.Operation, op.Algorithm)
+				if op.KeyPool != "" {
+					name = fmt.Sprintf("%s %s %s", phase.Name, op.Operation, op.KeyPool)
+				}
+				add(name, comparedMetrics{op.OpsPerSecond, op.ErrorRate, op.Latency})
+			}
+		}
+	}
+	if r.Ramp != nil {
+		for _, step := range r.Ramp.Steps {
+			for _, a := range step.Algorithms {
+				add(fmt.Sprintf("%s %v %s", r.Ramp.Dimension, step.Load, a.Algorithm), comparedMetrics{a.OpsPerSecond, errorRate(a), a.Latency})
+			}
+		}
+	}
+	return lines, names
+}
+
+// Formats the change from base to candidate as a percentage of base
+func relativeChange(base, candidate float64) string {
+	if base == 0 {
+		if candidate == 0 {
+			return "0.0%"
+		}
+		return "-"
+	}
+	change := 100 * (candidate - base) / base
+	if math.Abs(change) < 0.05 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%+.1f%%", change)
+}
+
+func formatClients(clients map[string]int) string {
+	var x []string
+	for algorithm, count := range clients {
+		x = append(x, fmt.Sprintf("%s=%d", algorithm, count))
+	}
+	sort.Strings(x)
+	return strings.Join(x, ",")
+}
+
+func formatKnee(r *RampResult) string {
+	if r.KneeLoad == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%s %v", r.Dimension, *r.KneeLoad)
+}
+
+func formatDrillsPassed(drills []DrillResult) string {
+	var passed int
+	for _, d := range drills {
+		if d.Passed {
+			passed++
+		}
+	}
+	return fmt.Sprintf("%d of %d", passed, len(drills))
+}
@@ -0,0 +1,82 @@
+package bench
+
+import (
+	"benchmark/test"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Returns the public key of a key, after checking that all players return the same public key. The session
+// checks a key rather than measures an operation, so it is a setup session.
+func (b *Benchmark) publicKey(a Algorithm, keyID string) ([]byte, error) {
+	return b.readPublicKey(a, keyID, nil, false)
+}
+
+// Returns the public key derived from a key along derivationPath, after checking that all players return the same
+// public key
+func (b *Benchmark) derivedPublicKey(a Algorithm, keyID string, derivationPath []uint32) ([]byte, error) {
+	return b.readPublicKey(a, keyID, derivationPath, true)
+}
+
+func (b *Benchmark) readPublicKey(a Algorithm, keyID string, derivationPath []uint32, recordTimings bool) ([]byte, error) {
+	publicKeys, err := runSessionRecording(b, b.clients, recordTimings, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]byte, error) {
+		if a.isECDSA() {
+			return client.ECDSA().PublicKey(ctx, keyID, derivationPath)
+		}
+		return client.Schnorr().PublicKey(ctx, keyID, derivationPath)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return publicKeys.AllEqual("public key", fmt.Sprintf("public key %s%v", keyID, derivationPath), bytes.Equal)
+}
+
+// Returns the chain code of a key, after checking that all players return the same chain code
+func (b *Benchmark) chainCode(a Algorithm, keyID string) ([]byte, error) {
+	chainCodes, err := runSetupSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]byte, error) {
+		if a.isECDSA() {
+			return client.ECDSA().ChainCode(ctx, keyID, nil)
+		}
+		return client.Schnorr().ChainCode(ctx, keyID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return chainCodes.AllEqual("chain code", "chain code "+keyID, bytes.Equal)
+}
+
+// Checks that all players agree on the public key and the chain code of a key
+func (b *Benchmark) checkKey(a Algorithm, keyID string) error {
+	if _, err := b.publicKey(a, keyID); err != nil {
+		return err
+	}
+	_, err := b.chainCode(a, keyID)
+	return err
+}
+
+// Generates a batch of presignatures for a key, and returns the presignature IDs after checking that all players
+// return the same IDs
+func (b *Benchmark) generatePresignatures(a Algorithm, keyID string, batchSize uint64) ([]string, error) {
+	sessionConfig := tsm.NewStaticSessionConfig(tsm.GenerateSessionID(), len(b.clients))
+	presigIDs, err := runSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]string, error) {
+		if a.isECDSA() {
+			return client.ECDSA().GeneratePresignatures(ctx, sessionConfig, keyID, batchSize)
+		}
+		return client.Schnorr().GeneratePresignatures(ctx, sessionConfig, keyID, batchSize)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return presigIDs.AllEqual("presignature IDs", sessionConfig.SessionID(), slices.Equal[[]string])
+}
+
+// Reports whether a session failed because the players disagreed on its result
+func isInconsistent(err error) bool {
+	var inconsistency *test.InconsistencyError
+	return errors.As(err, &inconsistency)
+}
@@ -0,0 +1,138 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm/tsmutils"
+)
+
+// A drill runs a fixed sequence of steps on a new key and checks the outcome, instead of measuring throughput
+var drills = map[string]func(b *Benchmark, a Algorithm, d *DrillResult) error{
+	"backupDrill":   (*Benchmark).backupDrill,
+	"recoveryDrill": (*Benchmark).recoveryDrill,
+}
+
+type DrillResult struct {
+	Drill     string      `json:"drill"`
+	Algorithm string      `json:"algorithm"`
+	KeyID     string      `json:"keyID,omitempty"`
+	Passed    bool        `json:"passed"`
+	Error     string      `json:"error,omitempty"`
+	Steps     []DrillStep `json:"steps"`
+}
+
+type DrillStep struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Runs f as a timed step of the drill
+func (d *DrillResult) step(name string, f func() error) error {
+	start := time.Now()
+	err := f()
+	d.Steps = append(d.Steps, DrillStep{Name: name, Seconds: time.Since(start).Seconds()})
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Runs the drill of the operation once per client, one after the other, so that the step timings are not affected by
+// other sessions
+func (b *Benchmark) benchmarkDrill() error {
+	drill := drills[b.operation]
+	for _, a := range b.algorithms {
+		for i := 0; i < a.clients && !b.stopped(); i++ {
+			d := DrillResult{Drill: b.operation, Algorithm: a.String()}
+			if err := drill(b, a.Algorithm, &d); err != nil {
+				d.Error = err.Error()
+				b.println(a, b.operation, "failed:", err)
+			} else {
+				d.Passed = true
+			}
+			if b.showProgress {
+				b.println(a, b.operation, i, "done")
+			}
+			b.drillResults = append(b.drillResults, d)
+		}
+	}
+	return nil
+}
+
+func (b *Benchmark) failedDrills() int {
+	failed := 0
+	for _, d := range b.drillResults {
+		if !d.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (p reportWriter) printDrills(drills []DrillResult) {
+	for _, d := range drills {
+		p.println()
+		if d.Passed {
+			p.printf("%s %s of key %s: passed\n", d.Algorithm, d.Drill, d.KeyID)
+		} else {
+			p.printf("%s %s of key %s: FAILED (%s)\n", d.Algorithm, d.Drill, d.KeyID, d.Error)
+		}
+		w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		for _, s := range d.Steps {
+			_, _ = fmt.Fprintf(w, " - %s\t%v\n", s.Name, time.Duration(s.Seconds*float64(time.Second)).Round(time.Microsecond))
+		}
+		_ = w.Flush()
+	}
+}
+
+// Signs with a random subset of signers, combines the partial signatures and verifies the signature locally against
+// the given public key
+func (b *Benchmark) signAndVerify(a Algorithm, keyID string, publicKey []byte) error {
+	message := a.signInput()
+
+	sessionConfig, selectedClients := subset(b.clients, b.signers)
+	partials, err := b.sign(a, sessionConfig, selectedClients, keyID, nil, message)
+	if err != nil {
+		return err
+	}
+
+	return verifySignature(a, publicKey, message, partials)
+}
+
+// Reports whether two JSON public keys hold the same point. This does not depend on the JSON encoding of the keys.
+func samePublicKey(a, b []byte) (bool, error) {
+	pointA, err := tsmutils.JSONPublicKeyToCompressedPoint(a)
+	if err != nil {
+		return false, err
+	}
+	pointB, err := tsmutils.JSONPublicKeyToCompressedPoint(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(pointA, pointB), nil
+}
+
+// Returns the scheme and curve name of a JSON public key
+func parsePublicKey(jsonPublicKey []byte) (scheme, curveName string, err error) {
+	var publicKey struct {
+		Scheme string `json:"scheme"`
+		Curve  string `json:"curve"`
+	}
+	if err := json.Unmarshal(jsonPublicKey, &publicKey); err != nil {
+		return "", "", fmt.Errorf("invalid public key: %w", err)
+	}
+	return publicKey.Scheme, publicKey.Curve, nil
+}
+
+// Deletes all players' shares of a key
+func (b *Benchmark) deleteKey(keyID string) error {
+	return b.runSetupSession(b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+		return client.KeyManagement().DeleteKeyShare(ctx, keyID)
+	})
+}
@@ -0,0 +1,55 @@
+package bench
+
+import "time"
+
+// EventType is the type of an Event
+type EventType int
+
+const (
+	// SessionDone is a session that completed successfully
+	SessionDone EventType = iota
+	// SessionFailed is a session that failed, or whose result failed a check
+	SessionFailed
+	// ClientStopped is a client that stopped starting sessions, because the test duration is over, the benchmark was
+	// stopped, or the client ran out of work
+	ClientStopped
+)
+
+func (t EventType) String() string {
+	switch t {
+	case SessionDone:
+		return "session done"
+	case SessionFailed:
+		return "session failed"
+	case ClientStopped:
+		return "client stopped"
+	default:
+		return "unknown event"
+	}
+}
+
+// Event is passed to Options.OnEvent as the benchmark runs. The events are reported from the goroutines of the
+// clients, so OnEvent must be safe for concurrent use, and should return quickly since the client waits for it.
+type Event struct {
+	Type EventType
+	Time time.Time
+	// The operation and algorithm of the session, such as sign and ECDSA/secp256k1. In scenario mode, the operation is
+	// the operation of the phase; a stopped client has operation scenario and no algorithm.
+	Operation string
+	Algorithm string
+	// The index of the client among the clients of the algorithm, or of the phase in scenario mode. In open-loop
+	// mode, the number of the session, counting from 1.
+	Client int
+	// The latency of a session that completed successfully
+	Latency time.Duration
+	// The error of a session that failed
+	Err error
+}
+
+// Reports an event to Options.OnEvent, if set
+func (b *Benchmark) emit(e Event) {
+	if b.onEvent != nil {
+		e.Time = time.Now()
+		b.onEvent(e)
+	}
+}
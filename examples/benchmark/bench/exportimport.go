@@ -0,0 +1,243 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm/tsmutils"
+)
+
+// State shared by the clients of operation exportImport
+type exportImportSetup struct {
+	// The key shares are exported under this wrapping key, so they can be unwrapped in-process. The nodes only export
+	// under wrapping keys in their ExportWhiteList.
+	wrappingKey    *rsa.PrivateKey
+	derWrappingKey []byte
+
+	// The wrapping keys of the players, used to import keys
+	playerWrappingKeys map[int]*rsa.PublicKey
+	players            []int
+
+	publicKeys map[Algorithm][]byte
+}
+
+// The steps of an export/import round trip that are counted on their own
+var exportImportSteps = []string{"export", "import", "sign"}
+
+// Each client repeatedly exports the shares of the benchmark key, rebuilds the private key locally and checks it against
+// the public key, then imports a new local key and signs with it. A round trip is only counted as an operation if all
+// of this succeeds; the export, import and sign steps are also counted on their own.
+func (b *Benchmark) benchmarkExportImport() error {
+	if err := b.generateKeys(); err != nil {
+		return err
+	}
+
+	setup := exportImportSetup{
+		playerWrappingKeys: map[int]*rsa.PublicKey{},
+		publicKeys:         map[Algorithm][]byte{},
+	}
+	var err error
+	setup.wrappingKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return err
+	}
+	setup.derWrappingKey, err = x509.MarshalPKIXPublicKey(&setup.wrappingKey.PublicKey)
+	if err != nil {
+		return err
+	}
+	wrappingKeys, err := runSetupSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) (*rsa.PublicKey, error) {
+		derWrappingKey, err := client.WrappingKey().WrappingKey(ctx)
+		if err != nil {
+			return nil, err
+		}
+		wrappingKey, err := x509.ParsePKIXPublicKey(derWrappingKey)
+		if err != nil {
+			return nil, err
+		}
+		rsaWrappingKey, ok := wrappingKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("wrapping key is not an RSA key")
+		}
+		return rsaWrappingKey, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error getting wrapping keys: %w", err)
+	}
+	setup.players = wrappingKeys.Players()
+	for _, playerIndex := range setup.players {
+		setup.playerWrappingKeys[playerIndex] = wrappingKeys[playerIndex].Result
+	}
+	for _, a := range b.algorithms {
+		if setup.publicKeys[a.Algorithm], err = b.publicKey(a.Algorithm, a.keyID); err != nil {
+			return err
+		}
+	}
+
+	counters := map[*algorithmState][]stepCounters{}
+	err = b.runClients(func(a *algorithmState, client int) Operation {
+		c := &exportImportClient{operationClient: operationClient{b, a, client}, setup: &setup, counters: newStepCounters(exportImportSteps)}
+		counters[a] = append(counters[a], c.counters)
+		return c
+	})
+	for _, a := range b.algorithms {
+		b.stepResults = append(b.stepResults, b.mergeStepCounters(a.String(), exportImportSteps, counters[a])...)
+	}
+	return err
+}
+
+// Runs export/import round trips with the benchmark key, and counts their steps in its own counters
+type exportImportClient struct {
+	operationClient
+	setup    *exportImportSetup
+	counters stepCounters
+}
+
+func (c *exportImportClient) Run() (func() error, error) {
+	return nil, c.b.exportImportRoundTrip(c.a.Algorithm, c.a.keyID, c.setup, c.counters)
+}
+
+func (b *Benchmark) exportImportRoundTrip(a Algorithm, keyID string, setup *exportImportSetup, counters stepCounters) error {
+	publicKey := setup.publicKeys[a]
+	_, curveName, err := parsePublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	var wrappedShares test.SessionResults[[]byte]
+	sessionConfig := test.CreateSessionConfig(b.clients)
+	err = timeOperation(counters["export"], func() error {
+		var err error
+		wrappedShares, err = runSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]byte, error) {
+			if a.isECDSA() {
+				result, err := client.ECDSA().ExportKeyShares(ctx, sessionConfig, keyID, nil, setup.derWrappingKey)
+				if err != nil {
+					return nil, err
+				}
+				return result.WrappedKeyShare, nil
+			}
+			result, err := client.Schnorr().ExportKeyShares(ctx, sessionConfig, keyID, nil, setup.derWrappingKey)
+			if err != nil {
+				return nil, err
+			}
+			return result.WrappedKeyShare, nil
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	shares := map[int][]byte{}
+	for playerIndex, wrappedShare := range wrappedShares {
+		if shares[playerIndex], err = tsmutils.Unwrap(setup.wrappingKey, wrappedShare.Result); err != nil {
+			return fmt.Errorf("unwrap share of player %d: %w", playerIndex, err)
+		}
+	}
+	privateKey, err := tsmutils.ShamirRecombine(b.threshold, shares, curveName)
+	if err != nil {
+		return fmt.Errorf("recombine exported shares: %w", err)
+	}
+	if err := checkPrivateKey(publicKey, privateKey); err != nil {
+		return fmt.Errorf("exported key: %w", err)
+	}
+
+	// A new key of the same size as the exported key; the size is the size of the group order of the curve
+	importedPublicKey, importShares, err := b.newImportKey(publicKey, len(privateKey), setup.players)
+	if err != nil {
+		return err
+	}
+	chainCode := make([]byte, 32)
+	if _, err := rand.Read(chainCode); err != nil {
+		return err
+	}
+
+	var importedKeyID string
+	sessionConfig = test.CreateSessionConfig(b.clients)
+	err = timeOperation(counters["import"], func() error {
+		keyIDs, err := runSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) (string, error) {
+			wrappedShare, err := tsmutils.Wrap(setup.playerWrappingKeys[playerIndex], importShares[playerIndex])
+			if err != nil {
+				return "", err
+			}
+			wrappedChainCode, err := tsmutils.Wrap(setup.playerWrappingKeys[playerIndex], chainCode)
+			if err != nil {
+				return "", err
+			}
+			if a.isECDSA() {
+				return client.ECDSA().ImportKeyShares(ctx, sessionConfig, b.threshold, wrappedShare, wrappedChainCode, importedPublicKey, "")
+			}
+			return client.Schnorr().ImportKeyShares(ctx, sessionConfig, b.threshold, wrappedShare, wrappedChainCode, importedPublicKey, "")
+		})
+		if err != nil {
+			return err
+		}
+		importedKeyID, err = keyIDs.AllEqual("key ID", sessionConfig.SessionID(), func(a, b string) bool { return a == b })
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	defer func() {
+		if err := b.deleteKey(importedKeyID); err != nil {
+			b.println("error deleting imported key", importedKeyID, ":", err)
+		}
+	}()
+
+	err = timeOperation(counters["sign"], func() error {
+		return b.signAndVerify(a, importedKeyID, importedPublicKey)
+	})
+	if err != nil {
+		return fmt.Errorf("sign with imported key: %w", err)
+	}
+	return nil
+}
+
+// Generates a random private key with the scheme and curve of the given public key, and returns the public key of the
+// new key and the Shamir shares of the new key for the players
+func (b *Benchmark) newImportKey(schemePublicKey []byte, size int, players []int) ([]byte, map[int][]byte, error) {
+	scheme, curveName, err := parsePublicKey(schemePublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey := make([]byte, size)
+	for attempt := 0; ; attempt++ {
+		if _, err := rand.Read(privateKey); err != nil {
+			return nil, nil, err
+		}
+		// Fails if the random value is not less than the group order
+		publicKey, err := tsmutils.PrivateKeyToJSONPublicKey(scheme, curveName, privateKey)
+		if err != nil {
+			if attempt < 1000 {
+				continue
+			}
+			return nil, nil, fmt.Errorf("unable to generate key to import: %w", err)
+		}
+		shares, err := tsmutils.ShamirSecretShare(b.threshold, players, curveName, privateKey)
+		return publicKey, shares, err
+	}
+}
+
+// Returns an error unless the private key belongs to the JSON public key
+func checkPrivateKey(jsonPublicKey, privateKey []byte) error {
+	scheme, curveName, err := parsePublicKey(jsonPublicKey)
+	if err != nil {
+		return err
+	}
+	publicKey, err := tsmutils.PrivateKeyToJSONPublicKey(scheme, curveName, privateKey)
+	if err != nil {
+		return err
+	}
+	same, err := samePublicKey(publicKey, jsonPublicKey)
+	if err != nil {
+		return err
+	}
+	if !same {
+		return fmt.Errorf("private key does not match the public key")
+	}
+	return nil
+}
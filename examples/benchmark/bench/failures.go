@@ -0,0 +1,188 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Categories of failed sessions
+const (
+	failureInvalidSignature  = "invalidSignature"
+	failureInconsistent      = "inconsistent"
+	failureHandshakeTimeout  = "handshakeTimeout"
+	failureProtocolAbort     = "protocolAbort"
+	failureContextDeadline   = "contextDeadline"
+	failureCancelled         = "cancelled"
+	failureAuth              = "authFailure"
+	failureConnectionRefused = "connectionRefused"
+	failureHTTP4xx           = "http4xx"
+	failureHTTP5xx           = "http5xx"
+	failureOther             = "other"
+)
+
+// The SDK includes the HTTP status code of a failed request in the error message, but does not wrap it in the error
+var httpStatusPattern = regexp.MustCompile(`node returned ([1-5])\d\d`)
+
+// Returns the category of the error of a failed session. The SDK passes on most node errors as text only, so apart
+// from the errors of the benchmark itself and the SDK sentinel errors, errors are classified by their message. The
+// order matters: a handshake timeout, for example, is reported by the node as an HTTP 500 whose message contains
+// "context deadline exceeded".
+func classifyFailure(err error) string {
+	message := err.Error()
+	switch {
+	case errors.Is(err, errInvalidSignature):
+		return failureInvalidSignature
+	case isInconsistent(err):
+		return failureInconsistent
+	case strings.Contains(message, "handshake"):
+		return failureHandshakeTimeout
+	case strings.Contains(message, "abort"):
+		return failureProtocolAbort
+	case errors.Is(err, context.DeadlineExceeded) || strings.Contains(message, "context deadline exceeded"):
+		return failureContextDeadline
+	case errors.Is(err, context.Canceled) || strings.Contains(message, "context canceled"):
+		return failureCancelled
+	case errors.Is(err, tsm.ErrAuthentication) || errors.Is(err, tsm.ErrAccess):
+		return failureAuth
+	case strings.Contains(message, "connection refused"):
+		return failureConnectionRefused
+	}
+	if status := httpStatusPattern.FindStringSubmatch(message); status != nil {
+		switch status[1] {
+		case "4":
+			return failureHTTP4xx
+		case "5":
+			return failureHTTP5xx
+		}
+	}
+	return failureOther
+}
+
+// Returns the index of the node whose player failed the session, or -1 if the failure is not due to a single player,
+// such as a disagreement between the players
+func failedNode(err error) int {
+	var playerError *test.PlayerError
+	if errors.As(err, &playerError) {
+		return playerError.Player
+	}
+	return -1
+}
+
+type failureKey struct {
+	category string
+	node     int
+}
+
+// Failed sessions by category and node. The zero value is ready to use, and the counts can be updated by several
+// clients at once.
+type failureCounts struct {
+	lock     sync.Mutex
+	counts   map[failureKey]uint64
+	examples map[failureKey]string
+}
+
+// Counts a failed session, and reports whether it is the first failure of its category on its node
+func (c *failureCounts) add(err error) bool {
+	key := failureKey{category: classifyFailure(err), node: failedNode(err)}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.counts == nil {
+		c.counts = map[failureKey]uint64{}
+		c.examples = map[failureKey]string{}
+	}
+	c.counts[key]++
+	if c.counts[key] > 1 {
+		return false
+	}
+	c.examples[key] = err.Error()
+	return true
+}
+
+// Adds the counts of other to c
+func (c *failureCounts) merge(other *failureCounts) {
+	for _, f := range other.list() {
+		key := failureKey{category: f.Category, node: f.Node}
+		c.lock.Lock()
+		if c.counts == nil {
+			c.counts = map[failureKey]uint64{}
+			c.examples = map[failureKey]string{}
+		}
+		if c.counts[key] == 0 {
+			c.examples[key] = f.Example
+		}
+		c.counts[key] += f.Count
+		c.lock.Unlock()
+	}
+}
+
+func (c *failureCounts) reset() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.counts, c.examples = nil, nil
+}
+
+// FailureCount is the number of sessions that failed with errors of a category on a node
+type FailureCount struct {
+	Category string `json:"category"`
+	// Index of the node, as given by the order of the -node flags, or -1 if the failure is not due to a single node
+	Node  int    `json:"node"`
+	Count uint64 `json:"count"`
+	// The error of the first of the failed sessions
+	Example string `json:"example"`
+}
+
+// Returns the counts ordered by category and node
+func (c *failureCounts) list() []FailureCount {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var failures []FailureCount
+	for key, count := range c.counts {
+		failures = append(failures, FailureCount{Category: key.category, Node: key.node, Count: count, Example: c.examples[key]})
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Category != failures[j].Category {
+			return failures[i].Category < failures[j].Category
+		}
+		return failures[i].Node < failures[j].Node
+	})
+	return failures
+}
+
+// Returns the fraction of the sessions that failed, given the number of successful and failed sessions
+func failureRate(operations, failed uint64) float64 {
+	if operations+failed == 0 {
+		return 0
+	}
+	return float64(failed) / float64(operations+failed)
+}
+
+// Formats failures as a list of categories, each with the failures per node, e.g.
+// "handshakeTimeout 12 (node 1: 10, node 2: 2) ; http5xx 3 (node 0: 3)"
+func formatFailures(failures []FailureCount) string {
+	var categories []string
+	for i := 0; i < len(failures); {
+		category := failures[i].Category
+		var total uint64
+		var nodes []string
+		for ; i < len(failures) && failures[i].Category == category; i++ {
+			total += failures[i].Count
+			if failures[i].Node >= 0 {
+				nodes = append(nodes, fmt.Sprintf("node %d: %d", failures[i].Node, failures[i].Count))
+			}
+		}
+		if len(nodes) > 0 {
+			categories = append(categories, fmt.Sprintf("%s %d (%s)", category, total, strings.Join(nodes, ", ")))
+		} else {
+			categories = append(categories, fmt.Sprintf("%s %d", category, total))
+		}
+	}
+	return strings.Join(categories, " ; ")
+}
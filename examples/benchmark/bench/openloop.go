@@ -0,0 +1,135 @@
+package bench
+
+import (
+	"benchmark/stats"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/rand"
+	"golang.org/x/sync/errgroup"
+)
+
+// A session that starts more than this after its scheduled start time is counted as a late start
+const lateStartThreshold = 10 * time.Millisecond
+
+// Runs the operation in open-loop mode: Sessions are started at the target arrival rate, regardless of how fast the
+// cluster completes them, and at most maxInFlight sessions per algorithm run at the same time.
+func (b *Benchmark) benchmarkOpenLoop() error {
+	var session func(a *algorithmState, derivationPath []uint32) error
+
+	switch b.operation {
+	case "sign":
+		if err := b.generateKeys(); err != nil {
+			return err
+		}
+		session = func(a *algorithmState, derivationPath []uint32) error {
+			check, err := b.signSession(a, derivationPath, "")
+			if err == nil && check != nil {
+				err = check()
+			}
+			return err
+		}
+	case "getpub":
+		if err := b.generateKeys(); err != nil {
+			return err
+		}
+		session = func(a *algorithmState, derivationPath []uint32) error {
+			_, err := b.derivedPublicKey(a.Algorithm, a.keyID, derivationPath)
+			return err
+		}
+	case "keygen":
+		session = func(a *algorithmState, _ []uint32) error {
+			return b.keygenSession(a.Algorithm)
+		}
+	default:
+		return fmt.Errorf("open-loop mode is not supported for operation %s", b.operation)
+	}
+
+	endTime := time.Now().Add(b.duration)
+	var eg errgroup.Group
+	for _, a := range b.algorithms {
+		a := a
+		eg.Go(func() error {
+			a.latency = b.runOpenLoop(a, endTime, func(derivationPath []uint32) error { return session(a, derivationPath) })
+			return nil
+		})
+	}
+
+	return eg.Wait()
+}
+
+// Schedules sessions until endTime and waits for the started sessions to complete. The latency of each session is
+// measured from its scheduled start time rather than its actual start time, so time spent waiting for a free slot or
+// for a late scheduler is included in the latency (i.e., the latency is corrected for coordinated omission).
+func (b *Benchmark) runOpenLoop(a *algorithmState, endTime time.Time, session func(derivationPath []uint32) error) *stats.Histogram {
+	var wg sync.WaitGroup
+	var latencyLock sync.Mutex
+	latency := stats.NewHistogram()
+	slots := make(chan struct{}, b.maxInFlight)
+
+	var sessionCount uint32
+	scheduled := time.Now()
+	for {
+		scheduled = scheduled.Add(b.interArrivalTime())
+		if scheduled.After(endTime) || !b.sleepUntil(scheduled) {
+			break
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			atomic.AddUint64(&a.dropped, 1)
+			if b.showProgress {
+				b.println(a, "session dropped;", b.maxInFlight, "sessions in flight")
+			}
+			continue
+		}
+		if time.Since(scheduled) > lateStartThreshold {
+			atomic.AddUint64(&a.late, 1)
+		}
+
+		sessionCount++
+		derivationPath := []uint32{1, 2, 3, 4, 5 + sessionCount}
+		sessionIndex := int(sessionCount)
+		wg.Add(1)
+		go func(scheduled time.Time) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			err := session(derivationPath)
+			elapsed := time.Since(scheduled)
+			if err != nil {
+				if a.countFailure(err, elapsed) || b.showProgress {
+					b.println(a, "session error:", err)
+				}
+				b.emit(Event{Type: SessionFailed, Operation: b.operation, Algorithm: a.String(), Client: sessionIndex, Err: err})
+				return
+			}
+
+			latencyLock.Lock()
+			latency.Record(elapsed)
+			latencyLock.Unlock()
+
+			opCount := atomic.AddUint64(&a.operations, 1)
+			if b.showProgress {
+				b.printf("%s operations: %05d\n", a, opCount)
+			}
+			b.emit(Event{Type: SessionDone, Operation: b.operation, Algorithm: a.String(), Client: sessionIndex, Latency: elapsed})
+		}(scheduled)
+	}
+
+	wg.Wait()
+	return latency
+}
+
+// Returns the time until the next scheduled session start
+func (b *Benchmark) interArrivalTime() time.Duration {
+	if b.arrivals == "poisson" {
+		return time.Duration(rand.ExpFloat64() / b.rate * float64(time.Second))
+	}
+	return time.Duration(float64(time.Second) / b.rate)
+}
@@ -0,0 +1,168 @@
+package bench
+
+import (
+	"benchmark/stats"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/rand"
+	"golang.org/x/sync/errgroup"
+)
+
+// Operation is a benchmark operation as run by one client. Each client of each algorithm gets its own Operation, so an
+// Operation can keep state between the sessions of its client, such as the derivation path of the next session.
+// runClients runs the Operations, and takes care of the test duration, the timing and counting of the sessions, the
+// progress output and the delay between sessions.
+type Operation interface {
+	// Setup prepares the client, before any client starts its sessions
+	Setup() error
+	// Run runs one session. The runner times the session; check, if not nil, is a check of the result of the session
+	// that is run after it, outside of its timing, and that fails the session if it fails. Run returns errClientDone
+	// when the client has no work left, such as when it has used up its presignatures.
+	Run() (check func() error, err error)
+	// Teardown is called when the client stops, after its last session
+	Teardown() error
+}
+
+// Returned by Operation.Run to stop its client
+var errClientDone = errors.New("client done")
+
+// Implemented by the Operations that add to the progress line printed after each session with -showProgress
+type progressReporter interface {
+	progress() string
+}
+
+// The operations of the -operation flag that run in a loop on each client, by the constructor of their Operation
+var clientOperations = map[string]func(b *Benchmark, a *algorithmState, client int) Operation{
+	"sign":       (*Benchmark).newSignClient,
+	"presigGen":  (*Benchmark).newPresigGenClient,
+	"onlineSign": (*Benchmark).newOnlineSignClient,
+	"getpub":     (*Benchmark).newGetPubClient,
+	"keygen":     (*Benchmark).newKeygenClient,
+}
+
+// Runs an Operation on each client of each algorithm until the test duration is over, the benchmark is stopped, or
+// the client is done. The Operations are created and set up one after the other before the clients start, and the
+// test duration starts when all clients are set up. The latency of the successful sessions of each algorithm is
+// merged into the latency of the algorithm. The background functions, such as the signers of -signDuringReshare, are
+// run alongside the clients with the same end time; they are not counted as operations.
+func (b *Benchmark) runClients(newOperation func(a *algorithmState, client int) Operation, background ...func(endTime time.Time)) error {
+	operations := map[*algorithmState][]Operation{}
+	for _, a := range b.algorithms {
+		for i := 0; i < a.clients; i++ {
+			op := newOperation(a, i)
+			if err := op.Setup(); err != nil {
+				return fmt.Errorf("error setting up %s client %d: %w", a, i, err)
+			}
+			operations[a] = append(operations[a], op)
+		}
+	}
+
+	endTime := time.Now().Add(b.duration)
+	var eg errgroup.Group
+	latencies := b.clientLatencies()
+	for _, a := range b.algorithms {
+		for i, op := range operations[a] {
+			a, i, op := a, i, op
+			eg.Go(func() error {
+				b.runClient(a, i, op, endTime, latencies[a][i])
+				return op.Teardown()
+			})
+		}
+	}
+	for _, f := range background {
+		f := f
+		eg.Go(func() error {
+			f(endTime)
+			return nil
+		})
+	}
+
+	err := eg.Wait()
+	mergeLatencies(latencies)
+	return err
+}
+
+func (b *Benchmark) runClient(a *algorithmState, i int, op Operation, endTime time.Time, latency *stats.Histogram) {
+	var failures int
+	for !b.done(endTime) {
+		sessionStart := time.Now()
+		check, err := op.Run()
+		if errors.Is(err, errClientDone) {
+			break
+		}
+		elapsed := time.Since(sessionStart)
+		if err == nil && check != nil {
+			err = check()
+		}
+		if err != nil {
+			if a.countFailure(err, time.Since(sessionStart)) || b.showProgress {
+				b.println(a, "client", i, "error:", err)
+			}
+			b.emit(Event{Type: SessionFailed, Operation: b.operation, Algorithm: a.String(), Client: i, Err: err})
+			failures++
+			b.backOff(failures)
+			continue
+		}
+		failures = 0
+
+		latency.Record(elapsed)
+		opCount := atomic.AddUint64(&a.operations, 1)
+		if b.showProgress {
+			var progress string
+			if reporter, ok := op.(progressReporter); ok {
+				progress = "; " + reporter.progress()
+			}
+			b.printf("%s operations: %05d; client %04d%s\n", a, opCount, i, progress)
+		}
+		b.emit(Event{Type: SessionDone, Operation: b.operation, Algorithm: a.String(), Client: i, Latency: elapsed})
+
+		b.randomDelay()
+	}
+	if b.showProgress {
+		b.println(a, "client", i, "stopped")
+	}
+	b.emit(Event{Type: ClientStopped, Operation: b.operation, Algorithm: a.String(), Client: i})
+}
+
+// Sleeps for a random time of up to -delay between two sessions of a client, or until the benchmark is stopped
+func (b *Benchmark) randomDelay() {
+	if b.delay > 0 {
+		b.sleepUntil(time.Now().Add(time.Duration(rand.Int63n(int64(b.delay)))))
+	}
+}
+
+// The wait of a client after its second consecutive failed session, which doubles with each further failure up to
+// maxFailureBackoff. This keeps a client whose sessions keep failing, such as on an unsupported curve, from flooding
+// the nodes with sessions, while an occasional failure is retried right away.
+const (
+	minFailureBackoff = 10 * time.Millisecond
+	maxFailureBackoff = time.Second
+)
+
+// Sleeps after a client failed this many sessions in a row, or until the benchmark is stopped
+func (b *Benchmark) backOff(failures int) {
+	if failures < 2 {
+		return
+	}
+	wait := min(minFailureBackoff<<min(failures-2, 10), maxFailureBackoff)
+	b.sleepUntil(time.Now().Add(wait))
+}
+
+// The state shared by the Operations of all operations: the client and its algorithm. It also provides the hooks
+// that most operations do not need.
+type operationClient struct {
+	b      *Benchmark
+	a      *algorithmState
+	client int
+}
+
+func (c *operationClient) Setup() error {
+	return nil
+}
+
+func (c *operationClient) Teardown() error {
+	return nil
+}
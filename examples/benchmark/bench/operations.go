@@ -0,0 +1,202 @@
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Signs with the benchmark key, with a random subset of the players and a new derivation path for each session
+type signClient struct {
+	operationClient
+	derivationPath []uint32
+}
+
+func (b *Benchmark) newSignClient(a *algorithmState, client int) Operation {
+	return &signClient{operationClient: operationClient{b, a, client}, derivationPath: []uint32{1, 2, 3, 4, 5}}
+}
+
+func (c *signClient) Setup() error {
+	return c.b.generateKeys()
+}
+
+func (c *signClient) Run() (func() error, error) {
+	c.derivationPath[4]++
+	if c.b.showProgress {
+		return c.b.signSession(c.a, c.derivationPath, fmt.Sprint(c.a, " signer ", c.client))
+	}
+	return c.b.signSession(c.a, c.derivationPath, "")
+}
+
+// Signs with the benchmark key of an algorithm with a random subset of the players, and returns the verification of
+// the signature as the check of the session if the signature is sampled for verification. If progress is set, the
+// players are printed after it.
+func (b *Benchmark) signSession(a *algorithmState, derivationPath []uint32, progress string) (func() error, error) {
+	message := a.signInput()
+	sessionConfig, selectedClients := subset(b.clients, b.signers)
+	if progress != "" {
+		players := make([]int, 0)
+		for selected := range selectedClients {
+			players = append(players, selected)
+		}
+		sort.Ints(players)
+		b.println(progress, "signing with players", players)
+	}
+	partials, err := b.sign(a.Algorithm, sessionConfig, selectedClients, a.keyID, derivationPath, message)
+	if err != nil || !b.sampleVerify() {
+		return nil, err
+	}
+	derivationPath = slices.Clone(derivationPath)
+	return func() error {
+		return b.verifySession(a.Algorithm, a.keyID, derivationPath, message, partials)
+	}, nil
+}
+
+// Generates presignatures for the benchmark key in batches, until presigCount presignatures are generated, and writes
+// their IDs to the presignature file of the client for onlineSign
+type presigGenClient struct {
+	operationClient
+	presigIDs []string
+}
+
+func (b *Benchmark) newPresigGenClient(a *algorithmState, client int) Operation {
+	return &presigGenClient{operationClient: operationClient{b, a, client}}
+}
+
+func (c *presigGenClient) Setup() error {
+	if err := c.b.generateKeys(); err != nil {
+		return err
+	}
+	return os.MkdirAll(c.b.presigDir, os.ModePerm)
+}
+
+func (c *presigGenClient) Run() (func() error, error) {
+	if len(c.presigIDs) >= c.b.presigCount {
+		return nil, errClientDone
+	}
+	presigIDs, err := c.b.generatePresignatures(c.a.Algorithm, c.a.keyID, c.b.presigBatchSize)
+	if err != nil {
+		return nil, err
+	}
+	c.presigIDs = append(c.presigIDs, presigIDs...)
+	return nil, nil
+}
+
+func (c *presigGenClient) progress() string {
+	percentage := (float64(len(c.presigIDs)) / float64(c.b.presigCount)) * 100.0
+	return fmt.Sprintf("generated presigs: %05d - %02.2f%%", len(c.presigIDs), percentage)
+}
+
+func (c *presigGenClient) Teardown() error {
+	out := newPresigIDs(c.a.Algorithm, c.a.keyID, c.presigIDs)
+	outBytes, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	filePath := c.b.presigFilePath(c.a.Algorithm, c.client)
+	if err = os.WriteFile(filePath, outBytes, 0644); err != nil {
+		return err
+	}
+	c.b.printf("%s client %04d done, writing %d presig IDs to file %s\n", c.a, c.client, len(c.presigIDs), filePath)
+	return nil
+}
+
+// Signs with the presignatures generated by the presigGen client with the same index, one presignature per session,
+// until they are used up
+type onlineSignClient struct {
+	operationClient
+	presigs        PresigIDs
+	derivationPath []uint32
+}
+
+func (b *Benchmark) newOnlineSignClient(a *algorithmState, client int) Operation {
+	return &onlineSignClient{operationClient: operationClient{b, a, client}, derivationPath: []uint32{1, 2, 3, 4, 5}}
+}
+
+func (c *onlineSignClient) Setup() error {
+	presigFilePath := c.b.presigFilePath(c.a.Algorithm, c.client)
+	jsonBytes, err := os.ReadFile(presigFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to read presigs from %s", presigFilePath)
+	}
+	if err = json.Unmarshal(jsonBytes, &c.presigs); err != nil {
+		return err
+	}
+	c.b.println(c.a, "client", c.client, "read", len(c.presigs.PresigIDs), "presig IDs from", presigFilePath)
+	return nil
+}
+
+func (c *onlineSignClient) Run() (func() error, error) {
+	if len(c.presigs.PresigIDs) == 0 {
+		return nil, errClientDone
+	}
+	c.derivationPath[4]++
+	var presigID string
+	presigID, c.presigs.PresigIDs = c.presigs.PresigIDs[0], c.presigs.PresigIDs[1:]
+	message := c.a.signInput()
+	partials, err := c.b.signWithPresignature(c.a.Algorithm, c.presigs.keyID(), presigID, c.derivationPath, message)
+	if err != nil || !c.b.sampleVerify() {
+		return nil, err
+	}
+	derivationPath := slices.Clone(c.derivationPath)
+	return func() error {
+		return c.b.verifySession(c.a.Algorithm, c.presigs.keyID(), derivationPath, message, partials)
+	}, nil
+}
+
+func (c *onlineSignClient) progress() string {
+	return fmt.Sprintf("presigs left: %05d", len(c.presigs.PresigIDs))
+}
+
+// Reads the public key derived from the benchmark key, along a new derivation path for each session
+type getPubClient struct {
+	operationClient
+	derivationPath []uint32
+}
+
+func (b *Benchmark) newGetPubClient(a *algorithmState, client int) Operation {
+	return &getPubClient{operationClient: operationClient{b, a, client}, derivationPath: []uint32{1, 2, 3, 4, 5}}
+}
+
+func (c *getPubClient) Setup() error {
+	return c.b.generateKeys()
+}
+
+func (c *getPubClient) Run() (func() error, error) {
+	c.derivationPath[4]++
+	_, err := c.b.derivedPublicKey(c.a.Algorithm, c.a.keyID, c.derivationPath)
+	return nil, err
+}
+
+// Generates a new key with all players in each session
+type keygenClient struct {
+	operationClient
+}
+
+func (b *Benchmark) newKeygenClient(a *algorithmState, client int) Operation {
+	return &keygenClient{operationClient{b, a, client}}
+}
+
+func (c *keygenClient) Run() (func() error, error) {
+	return nil, c.b.keygenSession(c.a.Algorithm)
+}
+
+// Generates a key with all players, letting the nodes choose the key ID
+func (b *Benchmark) keygenSession(a Algorithm) error {
+	keyID, err := b.runForKeyID(func(ctx context.Context, client *tsm.Client, sessionConfig *tsm.SessionConfig) (string, error) {
+		if a.isECDSA() {
+			return client.ECDSA().GenerateKey(ctx, sessionConfig, b.threshold, a.Curve, "")
+		}
+		return client.Schnorr().GenerateKey(ctx, sessionConfig, b.threshold, a.Curve, "")
+	})
+	if err != nil {
+		return err
+	}
+	b.recordKey(keyID)
+	return nil
+}
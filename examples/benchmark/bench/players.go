@@ -0,0 +1,162 @@
+package bench
+
+import (
+	"benchmark/stats"
+	"fmt"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// The time a player spent in its SDK call of a session
+type playerSpan struct {
+	start time.Time
+	end   time.Time
+}
+
+// Timing of the players across the sessions of the measured operations, used to find the nodes that hold up the sessions. The
+// start skew of a session is the time from the first to the last player joining; a node with a large skew towards the
+// other nodes is what makes the nodes run into their handshake timeout.
+type playerTimings struct {
+	lock     sync.Mutex
+	sessions uint64
+	skew     *stats.Histogram
+	nodes    map[int]*nodeTiming
+}
+
+// Timing of the player of one node, across the successful sessions it took part in
+type nodeTiming struct {
+	sessions uint64
+	// The number of sessions the node would be last in if all nodes were equally fast
+	fairShare    float64
+	latency      *stats.Histogram
+	lastToJoin   uint64
+	lastToFinish uint64
+}
+
+func newPlayerTimings() *playerTimings {
+	return &playerTimings{skew: stats.NewHistogram(), nodes: map[int]*nodeTiming{}}
+}
+
+// Records the timing of the players of a session. The start skew is recorded for every session with at least two
+// players. The latency of each player, and which players joined and finished last, is only recorded for successful
+// sessions, as the players of a failed session are cancelled when the first player fails.
+func (t *playerTimings) record(spans map[int]playerSpan, succeeded bool) {
+	if len(spans) < 2 {
+		return
+	}
+	firstStart, lastStart, lastEnd := -1, -1, -1
+	for playerIndex, span := range spans {
+		if firstStart < 0 || span.start.Before(spans[firstStart].start) {
+			firstStart = playerIndex
+		}
+		if lastStart < 0 || span.start.After(spans[lastStart].start) {
+			lastStart = playerIndex
+		}
+		if lastEnd < 0 || span.end.After(spans[lastEnd].end) {
+			lastEnd = playerIndex
+		}
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.skew.Record(spans[lastStart].start.Sub(spans[firstStart].start))
+	if !succeeded {
+		return
+	}
+	t.sessions++
+	for playerIndex, span := range spans {
+		node := t.nodes[playerIndex]
+		if node == nil {
+			node = &nodeTiming{latency: stats.NewHistogram()}
+			t.nodes[playerIndex] = node
+		}
+		node.sessions++
+		node.fairShare += 1 / float64(len(spans))
+		node.latency.Record(span.end.Sub(span.start))
+	}
+	t.nodes[lastStart].lastToJoin++
+	t.nodes[lastEnd].lastToFinish++
+}
+
+// Sessions a node must have taken part in before it can be called a straggler
+const minStragglerSessions = 10
+
+// A node is a persistent straggler if it is last in at least halfway between its fair share of the sessions it took
+// part in and all of them; e.g., in 75% of the sessions with two players, or 67% with three players
+func (n *nodeTiming) isStraggler(last uint64) bool {
+	return n.sessions >= minStragglerSessions && float64(last) >= n.fairShare+(float64(n.sessions)-n.fairShare)/2
+}
+
+// PlayerTimingResult holds the timing of the players of each node, across all sessions of the benchmark
+type PlayerTimingResult struct {
+	Sessions  uint64          `json:"sessions"`
+	StartSkew *LatencySummary `json:"startSkew,omitempty"`
+	Nodes     []NodeTiming    `json:"nodes"`
+	// The node that was persistently the last to finish or to join the sessions, if any
+	Straggler  *int `json:"straggler,omitempty"`
+	LateJoiner *int `json:"lateJoiner,omitempty"`
+}
+
+type NodeTiming struct {
+	Node         int             `json:"node"`
+	Sessions     uint64          `json:"sessions"`
+	Latency      *LatencySummary `json:"latency,omitempty"`
+	LastToJoin   uint64          `json:"lastToJoin"`
+	LastToFinish uint64          `json:"lastToFinish"`
+}
+
+func (b *Benchmark) playerTimingResult() *PlayerTimingResult {
+	t := b.playerTimings
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.skew.Count() == 0 {
+		return nil
+	}
+
+	r := &PlayerTimingResult{Sessions: t.sessions, StartSkew: newLatencySummary(t.skew)}
+	for playerIndex, node := range t.nodes {
+		r.Nodes = append(r.Nodes, NodeTiming{
+			Node:         playerIndex,
+			Sessions:     node.sessions,
+			Latency:      newLatencySummary(node.latency),
+			LastToJoin:   node.lastToJoin,
+			LastToFinish: node.lastToFinish,
+		})
+		if node.isStraggler(node.lastToFinish) {
+			r.Straggler = &playerIndex
+		}
+		if node.isStraggler(node.lastToJoin) {
+			r.LateJoiner = &playerIndex
+		}
+	}
+	sort.Slice(r.Nodes, func(i, j int) bool { return r.Nodes[i].Node < r.Nodes[j].Node })
+	return r
+}
+
+func (p reportWriter) printPlayerTimings(r *PlayerTimingResult) {
+	if r == nil {
+		return
+	}
+	p.println()
+	p.printf("Player timing (%d successful sessions):\n", r.Sessions)
+	s := r.StartSkew
+	p.printf(" - start skew: p50 %v ; p90 %v ; p99 %v ; max %v\n", fromMilliseconds(s.P50), fromMilliseconds(s.P90), fromMilliseconds(s.P99), fromMilliseconds(s.Max))
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Node\tSessions\tp50\tp99\tLast to join\tLast to finish")
+	for _, node := range r.Nodes {
+		p50, p99 := "-", "-"
+		if node.Latency != nil {
+			p50, p99 = fromMilliseconds(node.Latency.P50).String(), fromMilliseconds(node.Latency.P99).String()
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%d\t%d\n", node.Node, node.Sessions, p50, p99, node.LastToJoin, node.LastToFinish)
+	}
+	_ = w.Flush()
+	if r.Straggler != nil {
+		p.printf("Node %d is a persistent straggler: it was the last player to finish in most sessions\n", *r.Straggler)
+	}
+	if r.LateJoiner != nil {
+		p.printf("Node %d was the last player to join in most sessions\n", *r.LateJoiner)
+	}
+}
@@ -0,0 +1,140 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Preflight checks that the cluster is ready for the benchmark, and prints each check to Options.Log: that every node
+// reports its TSM version, that the nodes run the same version, which is compatible with the SDK, and that for each
+// algorithm of the clients a key can be generated, signed with by a random set of signers, and deleted. It returns an
+// error if any check failed. A Benchmark can either be checked or run, not both.
+func (b *Benchmark) Preflight(ctx context.Context) error {
+	b.ctx = ctx
+
+	var err error
+	b.clients, err = test.CreateClients(b.tsmConfigs)
+	if err != nil {
+		return err
+	}
+
+	var failed, checks int
+	check := func(name string, f func() (string, error)) bool {
+		checks++
+		start := time.Now()
+		detail, err := f()
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			failed++
+			b.printf("FAIL  %s (%v): %s\n", name, elapsed, err)
+			return false
+		}
+		if detail != "" {
+			name += ": " + detail
+		}
+		b.printf("ok    %s (%v)\n", name, elapsed)
+		return true
+	}
+
+	b.println("Checking", len(b.clients), "MPC nodes")
+	versions := map[int]*tsm.VersionInformation{}
+	for player := 0; player < len(b.clients); player++ {
+		check(fmt.Sprintf("node %d version", player), func() (string, error) {
+			v, err := b.tsmVersion(player)
+			if err != nil {
+				return "", err
+			}
+			versions[player] = v
+			return v.Version, nil
+		})
+	}
+	if len(versions) == len(b.clients) {
+		check("node versions", func() (string, error) {
+			return checkVersions(b.clients[0].SDKVersion(), versions)
+		})
+	}
+
+	for _, a := range b.algorithms {
+		var keyID string
+		var publicKey []byte
+		ok := check(a.String()+" keygen", func() (string, error) {
+			var err error
+			keyID, err = b.generateKey(a.Algorithm)
+			if err != nil {
+				return "", err
+			}
+			if err := b.checkKey(a.Algorithm, keyID); err != nil {
+				return "", err
+			}
+			publicKey, err = b.publicKey(a.Algorithm, keyID)
+			return keyID, err
+		})
+		if keyID == "" {
+			continue
+		}
+		if ok {
+			check(fmt.Sprintf("%s sign with %d of %d players and verify", a, b.signers, len(b.clients)), func() (string, error) {
+				return "", b.signAndVerify(a.Algorithm, keyID, publicKey)
+			})
+		}
+		check(a.String()+" delete key", func() (string, error) {
+			return "", b.deleteKey(keyID)
+		})
+	}
+
+	b.println()
+	if failed > 0 {
+		return fmt.Errorf("%d of %d preflight checks failed", failed, checks)
+	}
+	b.println("All", checks, "preflight checks passed")
+	return nil
+}
+
+// Returns the TSM version of a node. The SDK does not take a context for this, so the request is abandoned instead of
+// cancelled when the benchmark is cancelled or the session timeout expires.
+func (b *Benchmark) tsmVersion(player int) (*tsm.VersionInformation, error) {
+	ctx := b.ctx
+	if b.sessionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.sessionTimeout)
+		defer cancel()
+	}
+
+	type response struct {
+		version *tsm.VersionInformation
+		err     error
+	}
+	responses := make(chan response, 1)
+	go func() {
+		v, err := b.clients[player].TSMVersion()
+		responses <- response{v, err}
+	}()
+	select {
+	case r := <-responses:
+		return r.version, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Checks that all nodes run the same TSM version, with the same major client communication version as the SDK
+func checkVersions(sdkVersion *tsm.VersionInformation, versions map[int]*tsm.VersionInformation) (string, error) {
+	for player := 1; player < len(versions); player++ {
+		if *versions[player] != *versions[0] {
+			return "", fmt.Errorf("node 0 runs version %s and node %d runs version %s", versions[0].Version, player, versions[player].Version)
+		}
+	}
+	major := func(v string) string {
+		major, _, _ := strings.Cut(v, ".")
+		return major
+	}
+	if major(versions[0].ClientCommunication) != major(sdkVersion.ClientCommunication) {
+		return "", fmt.Errorf("the nodes use client communication version %s, which is incompatible with version %s of the SDK", versions[0].ClientCommunication, sdkVersion.ClientCommunication)
+	}
+	return versions[0].Version + ", SDK " + sdkVersion.Version, nil
+}
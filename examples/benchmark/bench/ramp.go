@@ -0,0 +1,222 @@
+package bench
+
+import (
+	"fmt"
+	"math"
+	"text/tabwriter"
+	"time"
+)
+
+type RampResult struct {
+	Mode         string       `json:"mode"`
+	Dimension    string       `json:"dimension"`
+	Start        float64      `json:"start"`
+	Increment    float64      `json:"increment"`
+	Max          float64      `json:"max"`
+	SLOP99Ms     float64      `json:"sloP99Ms,omitempty"`
+	SLOErrorRate float64      `json:"sloErrorRate"`
+	Steps        []StepResult `json:"steps"`
+	KneeLoad     *float64     `json:"kneeLoad,omitempty"`
+}
+
+type StepResult struct {
+	Load           float64           `json:"load"`
+	ElapsedSeconds float64           `json:"elapsedSeconds"`
+	WithinSLO      bool              `json:"withinSLO"`
+	Algorithms     []AlgorithmResult `json:"algorithms"`
+}
+
+// Returns what is being ramped: the number of clients per algorithm in closed-loop mode, or the arrival rate per
+// algorithm in open-loop mode
+func (b *Benchmark) rampDimension() string {
+	if b.rate > 0 {
+		return "rate"
+	}
+	return "clients"
+}
+
+// Runs the operation repeatedly with increasing load, holding each load level for the test duration. In steps mode,
+// every load level from rampStart to rampMax is tested. In search mode, the highest load level within the SLO is
+// found by bisection, down to a resolution of rampIncrement.
+func (b *Benchmark) benchmarkRamp() error {
+	if b.operation != "keygen" {
+		if err := b.generateKeys(); err != nil {
+			return err
+		}
+	}
+
+	switch b.ramp {
+	case "steps":
+		for i := 0; b.rampStart+float64(i)*b.rampIncrement <= b.rampMax; i++ {
+			step, err := b.runStep(b.rampStart + float64(i)*b.rampIncrement)
+			if err != nil {
+				return err
+			}
+			if b.stopped() {
+				// The load level of an interrupted step was not held for the test duration
+				return nil
+			}
+			if step.WithinSLO {
+				b.kneeLoad = &step.Load
+			}
+		}
+	case "search":
+		return b.rampSearch(b.runStep)
+	}
+
+	return nil
+}
+
+// Finds the highest load level within the SLO by bisection between rampStart and rampMax, running each load level with
+// runStep
+func (b *Benchmark) rampSearch(runStep func(load float64) (StepResult, error)) error {
+	lo, hi := b.rampStart, b.rampMax
+	step, err := runStep(lo)
+	if err != nil || !step.WithinSLO || b.stopped() {
+		return err
+	}
+	knee := lo
+	step, err = runStep(hi)
+	if err != nil {
+		return err
+	}
+	if step.WithinSLO && !b.stopped() {
+		b.kneeLoad = &hi
+		return nil
+	}
+	for hi-lo > b.rampIncrement && !b.stopped() {
+		mid := (lo + hi) / 2
+		if b.rampDimension() == "clients" {
+			mid = math.Round(mid)
+		}
+		if mid <= lo || mid >= hi {
+			break
+		}
+		step, err = runStep(mid)
+		if err != nil {
+			return err
+		}
+		if b.stopped() {
+			break
+		}
+		if step.WithinSLO {
+			lo, knee = mid, mid
+		} else {
+			hi = mid
+		}
+	}
+	b.kneeLoad = &knee
+	return nil
+}
+
+// Runs the operation at the given load and records the result as a ramp step
+func (b *Benchmark) runStep(load float64) (StepResult, error) {
+	if b.rate > 0 {
+		b.rate = load
+	} else {
+		for _, a := range b.algorithms {
+			a.clients = int(load)
+		}
+	}
+	b.resetCounters()
+
+	b.printf("Ramp step with %s %v\n", b.rampDimension(), load)
+	startTime := time.Now()
+	if err := b.runOperation(); err != nil {
+		return StepResult{}, fmt.Errorf("ramp step with %s %v failed: %w", b.rampDimension(), load, err)
+	}
+	elapsedSeconds := time.Since(startTime).Seconds()
+
+	step := StepResult{
+		Load:           load,
+		ElapsedSeconds: elapsedSeconds,
+	}
+	for _, a := range b.algorithms {
+		step.Algorithms = append(step.Algorithms, b.algorithmResult(a, elapsedSeconds))
+	}
+	step.WithinSLO = b.withinSLO(step.Algorithms)
+	b.rampSteps = append(b.rampSteps, step)
+
+	for _, a := range step.Algorithms {
+		b.printf(" - %s: %.2f ops/sec ; p99 %s ; error rate %.2f%%\n", a.Algorithm, a.OpsPerSecond, formatP99(a.Latency), 100*errorRate(a))
+	}
+	if step.WithinSLO {
+		b.println(" - within SLO")
+	} else {
+		b.println(" - SLO violated")
+	}
+
+	return step, nil
+}
+
+func (b *Benchmark) resetCounters() {
+	for _, a := range b.algorithms {
+		a.operations, a.errors, a.invalid, a.inconsistent, a.dropped, a.late, a.timeouts, a.lost = 0, 0, 0, 0, 0, 0, 0, 0
+		a.latency = nil
+		a.failures.reset()
+	}
+}
+
+// A step is within the SLO if every algorithm completed at least one session, the error rate is within the error
+// budget, and the p99 latency is within the latency objective
+func (b *Benchmark) withinSLO(algorithms []AlgorithmResult) bool {
+	for _, a := range algorithms {
+		if a.Operations == 0 || errorRate(a) > b.sloErrorRate {
+			return false
+		}
+		if b.sloP99 > 0 && (a.Latency == nil || a.Latency.P99 > milliseconds(b.sloP99)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the fraction of sessions that failed, produced an invalid signature, had players disagreeing on the result
+// or, in open-loop mode, could not be started
+func errorRate(a AlgorithmResult) float64 {
+	return failureRate(a.Operations, a.Errors+a.InvalidSignatures+a.Inconsistent+a.Dropped)
+}
+
+func formatP99(latency *LatencySummary) string {
+	if latency == nil {
+		return "-"
+	}
+	return fromMilliseconds(latency.P99).String()
+}
+
+func (p reportWriter) printRamp(r *RampResult) {
+	p.println()
+	p.printf("Ramp results (%s per algorithm):\n", r.Dimension)
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Load\tAlgorithm\tOps/sec\tp99\tError rate\tSLO")
+	for _, step := range r.Steps {
+		slo := "ok"
+		if !step.WithinSLO {
+			slo = "violated"
+		}
+		for _, a := range step.Algorithms {
+			_, _ = fmt.Fprintf(w, "%v\t%s\t%.2f\t%s\t%.2f%%\t%s\n", step.Load, a.Algorithm, a.OpsPerSecond, formatP99(a.Latency), 100*errorRate(a), slo)
+		}
+	}
+	_ = w.Flush()
+
+	if r.KneeLoad != nil {
+		p.printf("Highest load within SLO: %s %v\n", r.Dimension, *r.KneeLoad)
+	} else {
+		p.println("No load level was within SLO")
+	}
+}
+
+func (b *Benchmark) rampResult() *RampResult {
+	return &RampResult{
+		Mode:         b.ramp,
+		Dimension:    b.rampDimension(),
+		Start:        b.rampStart,
+		Increment:    b.rampIncrement,
+		Max:          b.rampMax,
+		SLOP99Ms:     milliseconds(b.sloP99),
+		SLOErrorRate: b.sloErrorRate,
+		Steps:        b.rampSteps,
+		KneeLoad:     b.kneeLoad,
+	}
+}
@@ -0,0 +1,76 @@
+package bench
+
+import (
+	"slices"
+	"testing"
+)
+
+// The search finds the highest load within the SLO, with steps that violate the SLO above a given load
+func TestRampSearch(t *testing.T) {
+	tests := []struct {
+		name                  string
+		start, increment, max float64
+		rate                  float64
+		sloLimit              float64
+		knee                  float64 // zero if no load is within the SLO
+		loads                 []float64
+	}{
+		{name: "clients", start: 10, increment: 1, max: 100, sloLimit: 80, knee: 80, loads: []float64{10, 100, 55, 78, 89, 84, 81, 80}},
+		{name: "coarse", start: 10, increment: 5, max: 100, sloLimit: 80, knee: 78, loads: []float64{10, 100, 55, 78, 89, 84, 81}},
+		{name: "rate", start: 10, increment: 5, max: 100, rate: 1, sloLimit: 80, knee: 77.5, loads: []float64{10, 100, 55, 77.5, 88.75, 83.125, 80.3125}},
+		{name: "max within SLO", start: 10, increment: 1, max: 100, sloLimit: 100, knee: 100, loads: []float64{10, 100}},
+		{name: "start violates SLO", start: 10, increment: 1, max: 100, sloLimit: 5, loads: []float64{10}},
+		{name: "start only", start: 10, increment: 1, max: 100, sloLimit: 10, knee: 10, loads: []float64{10, 100, 55, 33, 22, 16, 13, 12, 11}},
+	}
+	for _, tt := range tests {
+		b := newTestBenchmark(t, t.TempDir())
+		b.ramp = "search"
+		b.rampStart, b.rampIncrement, b.rampMax = tt.start, tt.increment, tt.max
+		b.rate = tt.rate
+		var loads []float64
+		runStep := func(load float64) (StepResult, error) {
+			loads = append(loads, load)
+			return StepResult{Load: load, WithinSLO: load <= tt.sloLimit}, nil
+		}
+		if err := b.rampSearch(runStep); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var knee float64
+		if b.kneeLoad != nil {
+			knee = *b.kneeLoad
+		}
+		if knee != tt.knee {
+			t.Errorf("%s: knee %v, want %v", tt.name, knee, tt.knee)
+		}
+		if !slices.Equal(loads, tt.loads) {
+			t.Errorf("%s: ran loads %v, want %v", tt.name, loads, tt.loads)
+		}
+	}
+}
+
+// A stopped search reports the highest load within the SLO found so far
+func TestRampSearchStopped(t *testing.T) {
+	b := newTestBenchmark(t, t.TempDir())
+	b.ramp = "search"
+	b.rampStart, b.rampIncrement, b.rampMax = 10, 1, 100
+	var loads []float64
+	runStep := func(load float64) (StepResult, error) {
+		loads = append(loads, load)
+		if len(loads) == 4 {
+			b.Stop()
+		}
+		return StepResult{Load: load, WithinSLO: load <= 80}, nil
+	}
+	if err := b.rampSearch(runStep); err != nil {
+		t.Fatal(err)
+	}
+	if b.kneeLoad == nil {
+		t.Fatal("no knee, want 55")
+	}
+	if *b.kneeLoad != 55 {
+		t.Errorf("knee %v, want 55", *b.kneeLoad)
+	}
+	if !slices.Equal(loads, []float64{10, 100, 55, 78}) {
+		t.Errorf("ran loads %v", loads)
+	}
+}
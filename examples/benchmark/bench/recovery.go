@@ -0,0 +1,135 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm/tsmutils"
+)
+
+// OAEP label of the recovery data generated by recoveryDrill
+var ersLabel = []byte("benchmark")
+
+// Checks that recovery data can be used to recover a key. The recovery data is encrypted under a throwaway RSA key
+// pair, which is created once per run and never leaves the process.
+func (b *Benchmark) recoveryDrill(a Algorithm, d *DrillResult) error {
+	if b.ersPrivateKey == nil {
+		err := d.step("create recovery key pair", func() error {
+			var err error
+			b.ersPrivateKey, err = rsa.GenerateKey(rand.Reader, 3072)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	ersPublicKey := &b.ersPrivateKey.PublicKey
+
+	var keyID string
+	var publicKey []byte
+	var err error
+	defer func() {
+		if keyID == "" {
+			return
+		}
+		if err := b.deleteKey(keyID); err != nil {
+			b.println("error deleting drill key", keyID, ":", err)
+		}
+	}()
+
+	err = d.step("keygen", func() error {
+		keyID, err = b.generateKey(a)
+		d.KeyID = keyID
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.step("public key", func() error {
+		publicKey, err = b.publicKey(a, keyID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var partialRecoveryData [][]byte
+	err = d.step("generate recovery data", func() error {
+		sessionConfig := test.CreateSessionConfig(b.clients)
+		partials, err := runSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]byte, error) {
+			if a.isECDSA() {
+				return client.ECDSA().GenerateRecoveryData(ctx, sessionConfig, keyID, ersPublicKey, ersLabel)
+			}
+			return client.Schnorr().GenerateRecoveryData(ctx, sessionConfig, keyID, ersPublicKey, ersLabel)
+		})
+		partialRecoveryData = partials.Values()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var recoveryData []byte
+	err = d.step("finalize recovery data", func() error {
+		if a.isECDSA() {
+			recoveryData, err = tsm.ECDSAFinalizeRecoveryData(partialRecoveryData, ersPublicKey, ersLabel)
+		} else {
+			recoveryData, err = tsm.SchnorrFinalizeRecoveryData(partialRecoveryData, ersPublicKey, ersLabel)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	err = d.step("validate recovery data", func() error {
+		if a.isECDSA() {
+			return tsm.ECDSAValidateRecoveryData(recoveryData, publicKey, ersPublicKey, ersLabel)
+		}
+		return tsm.SchnorrValidateRecoveryData(recoveryData, publicKey, ersPublicKey, ersLabel)
+	})
+	if err != nil {
+		return err
+	}
+
+	var recoveredPublicKey []byte
+	err = d.step("recover private key", func() error {
+		if a.isECDSA() {
+			recovered, err := tsm.ECDSARecoverPrivateKey(recoveryData, b.ersPrivateKey, ersLabel)
+			if err != nil {
+				return err
+			}
+			_, curveName, err := parsePublicKey(publicKey)
+			if err != nil {
+				return err
+			}
+			recoveredPublicKey, err = tsmutils.PrivateKeyToJSONPublicKey("ECDSA", curveName, recovered.PrivateKey)
+			return err
+		}
+		recovered, err := tsm.SchnorrRecoverPrivateKey(recoveryData, b.ersPrivateKey, ersLabel)
+		if err != nil {
+			return err
+		}
+		recoveredPublicKey, err = tsmutils.PrivateKeyToJSONPublicKey(recovered.SchnorrVariant, "", recovered.PrivateKey)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return d.step("check public key", func() error {
+		same, err := samePublicKey(recoveredPublicKey, publicKey)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return fmt.Errorf("public key of recovered private key does not match the public key of the key")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,113 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// WriteReport writes the human-readable report of a result, as printed by the benchmark when it ends
+func WriteReport(w io.Writer, r *Result) {
+	p := reportWriter{w}
+	if r.Stopped {
+		p.printf("Benchmark stopped after %v\n", seconds(r.ElapsedSeconds).Round(time.Millisecond))
+	}
+	if r.Error != "" {
+		p.printf("Benchmark failed after %v; the results are partial: %s\n", seconds(r.ElapsedSeconds).Round(time.Millisecond), r.Error)
+	}
+	switch {
+	case r.Scenario != nil:
+		p.printScenario(r.Scenario)
+	case r.Ramp != nil:
+		p.printRamp(r.Ramp)
+	case len(r.Drills) > 0:
+		p.printDrills(r.Drills)
+	default:
+		p.printAlgorithms(r)
+		p.printSignDuringReshare(r)
+		p.printSteps(r.Steps)
+	}
+	p.printPlayerTimings(r.Players)
+
+	if r.Parameters.FaultProxy != "" {
+		p.println()
+		p.println("Fault profiles:", formatFaultProfiles(r.FaultProfiles, r.StartTime))
+	}
+}
+
+// ReadResult reads a result written in format json. Results written by newer versions of the benchmark are rejected,
+// since their fields may have changed meaning.
+func ReadResult(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid result file %s; only results in format json can be read: %w", path, err)
+	}
+	if r.Version > ResultVersion {
+		return nil, fmt.Errorf("result file %s has version %d; this benchmark reads up to version %d", path, r.Version, ResultVersion)
+	}
+	return &r, nil
+}
+
+// Writes a report, ignoring write errors like the fmt.Print functions
+type reportWriter struct {
+	w io.Writer
+}
+
+func (p reportWriter) println(a ...any) {
+	_, _ = fmt.Fprintln(p.w, a...)
+}
+
+func (p reportWriter) printf(format string, a ...any) {
+	_, _ = fmt.Fprintf(p.w, format, a...)
+}
+
+func (p reportWriter) printAlgorithms(r *Result) {
+	for _, a := range r.Algorithms {
+		p.printf("%s operations with %d clients: %d (%.2f ops/sec ; %.2f ops/sec [e2e] ; %.2f%% errors)\n", a.Algorithm, a.Clients, a.Operations, a.OpsPerSecond, a.E2EOpsPerSecond, 100*a.ErrorRate)
+		if r.Parameters.Operation == "presigGen" {
+			p.printf(" - %.2f presigs/s\n", a.PresigsPerSecond)
+			p.printf(" - %.2f presigs/s [e2e]\n", a.E2EPresigsPerSecond)
+		}
+		if a.Errors > 0 {
+			p.printf(" - %d failed sessions\n", a.Errors)
+		}
+		if a.InvalidSignatures > 0 {
+			p.printf(" - %d invalid signatures\n", a.InvalidSignatures)
+		}
+		if a.Inconsistent > 0 {
+			p.printf(" - %d sessions where the players disagreed\n", a.Inconsistent)
+		}
+		if a.Timeouts > 0 {
+			p.printf(" - %d sessions timed out\n", a.Timeouts)
+		}
+		if a.LostSeconds > 0 {
+			p.printf(" - %v spent in failed sessions\n", seconds(a.LostSeconds).Round(time.Millisecond))
+		}
+		if len(a.Failures) > 0 {
+			p.println(" - failures:", formatFailures(a.Failures))
+		}
+		if r.Parameters.Rate > 0 {
+			p.printf(" - %.2f sessions/sec started (target %.2f) ; %d dropped ; %d late starts\n", a.AchievedRate, r.Parameters.Rate, a.Dropped, a.Late)
+		}
+		p.printLatency(a.Latency)
+	}
+}
+
+// Prints the latency distribution of the successful sessions of an operation
+func (p reportWriter) printLatency(l *LatencySummary) {
+	if l == nil {
+		return
+	}
+	p.printf(" - latency: min %v ; mean %v ; p50 %v ; p90 %v ; p99 %v ; p99.9 %v ; max %v\n",
+		fromMilliseconds(l.Min), fromMilliseconds(l.Mean), fromMilliseconds(l.P50), fromMilliseconds(l.P90), fromMilliseconds(l.P99), fromMilliseconds(l.P999), fromMilliseconds(l.Max))
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,156 @@
+package bench
+
+import (
+	"benchmark/random"
+	"benchmark/stats"
+	"benchmark/test"
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"golang.org/x/exp/rand"
+)
+
+// The ID of a key that is being reshared. It is set by the Setup of its reshare client, before the clients and the
+// signers start.
+type reshareKey struct {
+	keyID string
+}
+
+// Each reshare client generates its own key and reshares it in a loop. If signDuringReshare is set, that many
+// clients per algorithm sign with the same keys while they are being reshared.
+func (b *Benchmark) benchmarkReshare() error {
+	keys := map[*algorithmState][]*reshareKey{}
+	newReshareClient := func(a *algorithmState, client int) Operation {
+		key := &reshareKey{}
+		keys[a] = append(keys[a], key)
+		return &reshareClient{operationClient: operationClient{b, a, client}, key: key}
+	}
+
+	signCounters := map[*algorithmState][]*operationCounters{}
+	var signers []func(endTime time.Time)
+	for _, a := range b.algorithms {
+		for i := 0; i < b.signDuringReshare; i++ {
+			a, i := a, i
+			counters := &operationCounters{latency: stats.NewHistogram()}
+			signCounters[a] = append(signCounters[a], counters)
+			signers = append(signers, func(endTime time.Time) {
+				b.signDuringReshareLoop(a.Algorithm, i, keys[a], endTime, counters)
+			})
+		}
+	}
+
+	err := b.runClients(newReshareClient, signers...)
+
+	for _, a := range b.algorithms {
+		if len(signCounters[a]) == 0 {
+			continue
+		}
+		merged := operationCounters{latency: stats.NewHistogram()}
+		for _, counters := range signCounters[a] {
+			merged.merge(counters)
+		}
+		b.signDuringReshareResults = append(b.signDuringReshareResults, merged.result("sign", a.String(), b.runDuration().Seconds()))
+	}
+
+	return err
+}
+
+// Reshares the key of the client. If -reshareThreshold is set, the key is first copied to a new key with that
+// threshold, as resharing keeps the threshold of a key, and the copy is reshared.
+type reshareClient struct {
+	operationClient
+	key *reshareKey
+}
+
+func (c *reshareClient) Setup() error {
+	b, a := c.b, c.a
+	keyID, err := b.generateKey(a.Algorithm)
+	if err != nil {
+		return fmt.Errorf("error running keygen for %s: %w", a, err)
+	}
+	if b.reshareThreshold == 0 {
+		b.recordKey(keyID)
+		c.key.keyID = keyID
+		return nil
+	}
+
+	copyKeyID := random.String(20)
+	sessionConfig := test.CreateSessionConfig(b.clients)
+	err = b.runSetupSession(b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+		var err error
+		if a.isECDSA() {
+			_, err = client.ECDSA().CopyKey(ctx, sessionConfig, keyID, "", b.reshareThreshold, copyKeyID)
+		} else {
+			_, err = client.Schnorr().CopyKey(ctx, sessionConfig, keyID, "", b.reshareThreshold, copyKeyID)
+		}
+		return err
+	})
+	if err != nil {
+		b.recordKey(keyID)
+		return fmt.Errorf("error copying %s key to threshold %d: %w", a, b.reshareThreshold, err)
+	}
+	b.recordKey(copyKeyID)
+	if err := b.deleteKey(keyID); err != nil {
+		b.recordKey(keyID)
+		return fmt.Errorf("error deleting %s key after copying it: %w", a, err)
+	}
+	c.key.keyID = copyKeyID
+	return nil
+}
+
+func (c *reshareClient) Run() (func() error, error) {
+	b, a := c.b, c.a
+	keyID := c.key.keyID
+	sessionConfig := test.CreateSessionConfig(b.clients)
+	err := b.runSession(b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+		if a.isECDSA() {
+			return client.ECDSA().Reshare(ctx, sessionConfig, keyID)
+		}
+		return client.Schnorr().Reshare(ctx, sessionConfig, keyID)
+	})
+	return nil, err
+}
+
+func (b *Benchmark) signDuringReshareLoop(a Algorithm, i int, keys []*reshareKey, endTime time.Time, counters *operationCounters) {
+	message := a.signInput()
+	derivationPath := []uint32{1, 2, 3, 4, 5}
+	var failures int
+	for !b.done(endTime) {
+		keyID := keys[rand.Intn(len(keys))].keyID
+		derivationPath[4]++
+		sessionConfig, selectedClients := subset(b.clients, b.signers)
+		sessionStart := time.Now()
+		_, err := b.sign(a, sessionConfig, selectedClients, keyID, derivationPath, message)
+		if err != nil {
+			if counters.countFailure(err, time.Since(sessionStart)) || b.showProgress {
+				b.println(a, "signer", i, "error during reshare:", err)
+			}
+			failures++
+			b.backOff(failures)
+			continue
+		}
+		failures = 0
+		counters.latency.Record(time.Since(sessionStart))
+		counters.operations++
+	}
+}
+
+func (p reportWriter) printSignDuringReshare(result *Result) {
+	for _, r := range result.SignDuringReshare {
+		p.printf("%s signatures during reshare with %d clients: %d (%.2f ops/sec ; %d failed sessions ; %.2f%% errors)\n", r.Algorithm, result.Parameters.SignDuringReshare, r.Operations, r.OpsPerSecond, r.Errors, 100*r.ErrorRate)
+		if r.Timeouts > 0 {
+			p.printf(" - %d sessions timed out\n", r.Timeouts)
+		}
+		if r.LostSeconds > 0 {
+			p.printf(" - %v spent in failed sessions\n", seconds(r.LostSeconds).Round(time.Millisecond))
+		}
+		if len(r.Failures) > 0 {
+			p.println(" - failures:", formatFailures(r.Failures))
+		}
+		if r.Latency != nil {
+			p.printf(" - latency: min %v ; p50 %v ; p99 %v ; max %v\n", fromMilliseconds(r.Latency.Min), fromMilliseconds(r.Latency.P50), fromMilliseconds(r.Latency.P99), fromMilliseconds(r.Latency.Max))
+		}
+	}
+}
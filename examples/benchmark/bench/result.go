@@ -0,0 +1,405 @@
+package bench
+
+import (
+	"benchmark/faults"
+	"benchmark/stats"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ResultVersion is incremented whenever a field of Result is renamed or removed, or its meaning changes
+const ResultVersion = 2
+
+type Result struct {
+	Version        int        `json:"version"`
+	Parameters     Parameters `json:"parameters"`
+	StartTime      time.Time  `json:"startTime"`
+	EndTime        time.Time  `json:"endTime"`
+	ElapsedSeconds float64    `json:"elapsedSeconds"`
+	// Set if the benchmark was stopped before the end of the test duration; rates are then computed for the time the
+	// benchmark actually ran
+	Stopped bool `json:"stopped,omitempty"`
+	// The error that ended the benchmark, if it failed; the results are then for the part of the benchmark that ran
+	Error      string            `json:"error,omitempty"`
+	Algorithms []AlgorithmResult `json:"algorithms,omitempty"`
+	// Signatures made with the keys while they were being reshared
+	SignDuringReshare []OperationResult `json:"signDuringReshare,omitempty"`
+	// The steps of the exportImport and bip32 operations, counted on their own
+	Steps    []OperationResult `json:"steps,omitempty"`
+	Ramp     *RampResult       `json:"ramp,omitempty"`
+	Scenario *ScenarioResult   `json:"scenario,omitempty"`
+	Drills   []DrillResult     `json:"drills,omitempty"`
+	// Timing of the players of each node, and the node holding up the sessions, if any
+	Players *PlayerTimingResult `json:"players,omitempty"`
+	// The fault profiles of the fault proxy that were active during the run, if any
+	FaultProfiles []faults.ProfileChange `json:"faultProfiles,omitempty"`
+}
+
+// TestDuration returns for how long the benchmark was set to run: the total duration of the phases of a scenario, or
+// the test duration otherwise
+func (r *Result) TestDuration() time.Duration {
+	if r.Scenario == nil {
+		return seconds(r.Parameters.DurationSeconds)
+	}
+	var d float64
+	for _, phase := range r.Scenario.Phases {
+		d += phase.DurationSeconds
+	}
+	return seconds(d)
+}
+
+type Parameters struct {
+	Operation         string         `json:"operation"`
+	Nodes             []string       `json:"nodes"`
+	Clients           map[string]int `json:"clients,omitempty"`
+	Threshold         int            `json:"threshold"`
+	Signers           int            `json:"signers"`
+	DurationSeconds   float64        `json:"durationSeconds"`
+	DelaySeconds      float64        `json:"delaySeconds"`
+	SessionTimeout    float64        `json:"sessionTimeoutSeconds,omitempty"`
+	FaultProxy        string         `json:"faultProxy,omitempty"`
+	VerifyRate        float64        `json:"verifyRate,omitempty"`
+	ReshareThreshold  int            `json:"reshareThreshold,omitempty"`
+	SignDuringReshare int            `json:"signDuringReshare,omitempty"`
+	BIP32Path         string         `json:"bip32Path,omitempty"`
+	PresigCount       int            `json:"presigCount,omitempty"`
+	PresigBatchSize   uint64         `json:"presigBatchSize,omitempty"`
+	PresigDir         string         `json:"presigDir,omitempty"`
+	Rate              float64        `json:"rate,omitempty"`
+	Arrivals          string         `json:"arrivals,omitempty"`
+	MaxInFlight       int            `json:"maxInFlight,omitempty"`
+}
+
+type AlgorithmResult struct {
+	Algorithm         string  `json:"algorithm"`
+	Scheme            string  `json:"scheme,omitempty"`
+	Curve             string  `json:"curve,omitempty"`
+	Clients           int     `json:"clients"`
+	Operations        uint64  `json:"operations"`
+	Errors            uint64  `json:"errors"`
+	InvalidSignatures uint64  `json:"invalidSignatures,omitempty"`
+	Inconsistent      uint64  `json:"inconsistent,omitempty"`
+	Timeouts          uint64  `json:"timeouts,omitempty"`
+	LostSeconds       float64 `json:"lostSeconds,omitempty"`
+	// Fraction of the sessions that failed for any reason, including sessions dropped in open-loop mode
+	ErrorRate float64 `json:"errorRate"`
+	// Failed sessions by error category and node
+	Failures            []FailureCount  `json:"failures,omitempty"`
+	OpsPerSecond        float64         `json:"opsPerSecond"`
+	E2EOpsPerSecond     float64         `json:"e2eOpsPerSecond"`
+	PresigsPerSecond    float64         `json:"presigsPerSecond,omitempty"`
+	E2EPresigsPerSecond float64         `json:"e2ePresigsPerSecond,omitempty"`
+	AchievedRate        float64         `json:"achievedRate,omitempty"`
+	Dropped             uint64          `json:"dropped,omitempty"`
+	Late                uint64          `json:"late,omitempty"`
+	Latency             *LatencySummary `json:"latency,omitempty"`
+}
+
+// LatencySummary holds the latency distribution of the successful sessions, in milliseconds
+type LatencySummary struct {
+	Count uint64  `json:"count"`
+	Min   float64 `json:"minMs"`
+	Mean  float64 `json:"meanMs"`
+	P50   float64 `json:"p50Ms"`
+	P90   float64 `json:"p90Ms"`
+	P99   float64 `json:"p99Ms"`
+	P999  float64 `json:"p999Ms"`
+	Max   float64 `json:"maxMs"`
+}
+
+func newLatencySummary(h *stats.Histogram) *LatencySummary {
+	if h == nil || h.Count() == 0 {
+		return nil
+	}
+	return &LatencySummary{
+		Count: h.Count(),
+		Min:   milliseconds(h.Min()),
+		Mean:  milliseconds(h.Mean()),
+		P50:   milliseconds(h.Quantile(0.5)),
+		P90:   milliseconds(h.Quantile(0.9)),
+		P99:   milliseconds(h.Quantile(0.99)),
+		P999:  milliseconds(h.Quantile(0.999)),
+		Max:   milliseconds(h.Max()),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func fromMilliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond)).Round(time.Microsecond)
+}
+
+func (b *Benchmark) result(startTime, endTime time.Time) Result {
+	r := Result{
+		Version: ResultVersion,
+		Parameters: Parameters{
+			Operation:       b.operation,
+			Threshold:       b.threshold,
+			Signers:         b.signers,
+			DurationSeconds: b.duration.Seconds(),
+			DelaySeconds:    b.delay.Seconds(),
+			VerifyRate:      b.verifyRate,
+			SessionTimeout:  b.sessionTimeout.Seconds(),
+			FaultProxy:      b.faultProxy,
+		},
+		StartTime:      startTime,
+		EndTime:        endTime,
+		ElapsedSeconds: endTime.Sub(startTime).Seconds(),
+		Stopped:        b.stopped(),
+		Players:        b.playerTimingResult(),
+		FaultProfiles:  b.faultProfiles,
+	}
+
+	// The configured URLs never contain the API keys
+	for i := 0; i < len(b.tsmConfigs); i++ {
+		r.Parameters.Nodes = append(r.Parameters.Nodes, b.tsmConfigs[i].URL)
+	}
+
+	if len(b.algorithms) > 0 {
+		r.Parameters.Clients = map[string]int{}
+		for _, a := range b.algorithms {
+			r.Parameters.Clients[a.String()] = a.clients
+		}
+	}
+	if b.operation == "reshare" {
+		r.Parameters.ReshareThreshold = b.reshareThreshold
+		r.Parameters.SignDuringReshare = b.signDuringReshare
+		r.SignDuringReshare = b.signDuringReshareResults
+	}
+	r.Steps = b.stepResults
+	if b.operation == "bip32" {
+		r.Parameters.BIP32Path = b.bip32PathFlag
+	}
+	if b.operation == "presigGen" || b.operation == "onlineSign" {
+		r.Parameters.PresigDir = b.presigDir
+	}
+	if b.operation == "presigGen" {
+		r.Parameters.PresigCount = b.presigCount
+		r.Parameters.PresigBatchSize = b.presigBatchSize
+	}
+
+	if b.rate > 0 {
+		r.Parameters.Rate = b.rate
+		r.Parameters.Arrivals = b.arrivals
+		r.Parameters.MaxInFlight = b.maxInFlight
+	}
+
+	if b.scenario != nil {
+		r.Parameters.Operation = "scenario"
+		r.Scenario = &ScenarioResult{File: b.scenarioFile, Phases: b.phaseResults}
+		return r
+	}
+	if b.ramp != "" {
+		r.Ramp = b.rampResult()
+		return r
+	}
+	if drills[b.operation] != nil {
+		r.Drills = b.drillResults
+		return r
+	}
+
+	for _, a := range b.algorithms {
+		r.Algorithms = append(r.Algorithms, b.algorithmResult(a, r.ElapsedSeconds))
+	}
+
+	return r
+}
+
+func (b *Benchmark) algorithmResult(s *algorithmState, elapsedSeconds float64) AlgorithmResult {
+	a := AlgorithmResult{
+		Algorithm:         s.String(),
+		Scheme:            s.Scheme,
+		Curve:             s.Curve,
+		Clients:           s.clients,
+		Operations:        s.operations,
+		Errors:            s.errors,
+		InvalidSignatures: s.invalid,
+		Inconsistent:      s.inconsistent,
+		Timeouts:          s.timeouts,
+		LostSeconds:       s.lostTime().Seconds(),
+		Dropped:           s.dropped,
+		Late:              s.late,
+		Failures:          s.failures.list(),
+	}
+	a.ErrorRate = errorRate(a)
+	a.OpsPerSecond = float64(a.Operations) / b.runDuration().Seconds()
+	a.E2EOpsPerSecond = float64(a.Operations) / elapsedSeconds
+	a.Latency = newLatencySummary(s.latency)
+	if b.rate > 0 {
+		a.AchievedRate = float64(a.Operations+a.Errors+a.InvalidSignatures+a.Inconsistent) / b.runDuration().Seconds()
+	}
+	if b.operation == "presigGen" {
+		a.PresigsPerSecond = a.OpsPerSecond * float64(b.presigBatchSize)
+		a.E2EPresigsPerSecond = a.E2EOpsPerSecond * float64(b.presigBatchSize)
+	}
+	return a
+}
+
+// WriteResult writes a result to a file, in format json or csv
+func WriteResult(r Result, path, format string) error {
+	var data []byte
+	switch format {
+	case "json":
+		var err error
+		data, err = json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+	case "csv":
+		return writeResultCSV(r, path)
+	default:
+		return fmt.Errorf("invalid output format: %s", format)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+var csvHeader = []string{
+	"version", "operation", "algorithm", "startTime", "endTime", "elapsedSeconds", "nodes", "clients", "threshold",
+	"signers", "durationSeconds", "delaySeconds", "presigCount", "presigBatchSize", "rate", "arrivals", "maxInFlight",
+	"operations", "errors", "opsPerSecond", "e2eOpsPerSecond", "presigsPerSecond", "e2ePresigsPerSecond",
+	"achievedRate", "dropped", "late", "latencyCount", "latencyMinMs", "latencyMeanMs", "latencyP50Ms", "latencyP90Ms",
+	"latencyP99Ms", "latencyP999Ms", "latencyMaxMs", "rampLoad", "withinSLO", "phase",
+	"keyPool", "drillStep", "passed", "verifyRate", "invalidSignatures", "inconsistent",
+	"sessionTimeoutSeconds", "timeouts", "lostSeconds", "errorRate", "failures",
+	"faultProfiles",
+}
+
+// Writes one row per algorithm, each row repeating the run parameters. In ramp mode, one row is written per algorithm
+// and ramp step, and for a drill, one row is written per drill step.
+func writeResultCSV(r Result, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+
+	w := csv.NewWriter(f)
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+	type csvRow struct {
+		algorithm      AlgorithmResult
+		elapsedSeconds float64
+		operation      string
+		rampLoad       string
+		withinSLO      string
+		phase          string
+		keyPool        string
+		drillStep      string
+		passed         string
+	}
+	var rows []csvRow
+	for _, a := range r.Algorithms {
+		rows = append(rows, csvRow{algorithm: a, elapsedSeconds: r.ElapsedSeconds, operation: r.Parameters.Operation})
+	}
+	if r.Ramp != nil {
+		for _, step := range r.Ramp.Steps {
+			for _, a := range step.Algorithms {
+				rows = append(rows, csvRow{algorithm: a, elapsedSeconds: step.ElapsedSeconds, operation: r.Parameters.Operation,
+					rampLoad: formatFloat(step.Load), withinSLO: strconv.FormatBool(step.WithinSLO)})
+			}
+		}
+	}
+	if r.Scenario != nil {
+		for _, phase := range r.Scenario.Phases {
+			for _, op := range phase.Operations {
+				a := AlgorithmResult{
+					Algorithm:    op.Algorithm,
+					Clients:      phase.Clients,
+					Operations:   op.Operations,
+					Errors:       op.Errors,
+					ErrorRate:    op.ErrorRate,
+					Failures:     op.Failures,
+					Timeouts:     op.Timeouts,
+					LostSeconds:  op.LostSeconds,
+					OpsPerSecond: op.OpsPerSecond,
+					Latency:      op.Latency,
+				}
+				rows = append(rows, csvRow{algorithm: a, elapsedSeconds: phase.ElapsedSeconds, operation: op.Operation,
+					phase: phase.Name, keyPool: op.KeyPool})
+			}
+		}
+	}
+
+	for _, d := range r.Drills {
+		for _, step := range d.Steps {
+			rows = append(rows, csvRow{algorithm: AlgorithmResult{Algorithm: d.Algorithm}, elapsedSeconds: step.Seconds,
+				operation: d.Drill, drillStep: step.Name, passed: strconv.FormatBool(d.Passed)})
+		}
+	}
+
+	for _, row := range rows {
+		a := row.algorithm
+		latency := a.Latency
+		if latency == nil {
+			latency = &LatencySummary{}
+		}
+		record := []string{
+			strconv.Itoa(r.Version),
+			row.operation,
+			a.Algorithm,
+			r.StartTime.Format(time.RFC3339Nano),
+			r.EndTime.Format(time.RFC3339Nano),
+			formatFloat(row.elapsedSeconds),
+			strconv.Itoa(len(r.Parameters.Nodes)),
+			strconv.Itoa(a.Clients),
+			strconv.Itoa(r.Parameters.Threshold),
+			strconv.Itoa(r.Parameters.Signers),
+			formatFloat(r.Parameters.DurationSeconds),
+			formatFloat(r.Parameters.DelaySeconds),
+			strconv.Itoa(r.Parameters.PresigCount),
+			strconv.FormatUint(r.Parameters.PresigBatchSize, 10),
+			formatFloat(r.Parameters.Rate),
+			r.Parameters.Arrivals,
+			strconv.Itoa(r.Parameters.MaxInFlight),
+			strconv.FormatUint(a.Operations, 10),
+			strconv.FormatUint(a.Errors, 10),
+			formatFloat(a.OpsPerSecond),
+			formatFloat(a.E2EOpsPerSecond),
+			formatFloat(a.PresigsPerSecond),
+			formatFloat(a.E2EPresigsPerSecond),
+			formatFloat(a.AchievedRate),
+			strconv.FormatUint(a.Dropped, 10),
+			strconv.FormatUint(a.Late, 10),
+			strconv.FormatUint(latency.Count, 10),
+			formatFloat(latency.Min),
+			formatFloat(latency.Mean),
+			formatFloat(latency.P50),
+			formatFloat(latency.P90),
+			formatFloat(latency.P99),
+			formatFloat(latency.P999),
+			formatFloat(latency.Max),
+			row.rampLoad,
+			row.withinSLO,
+			row.phase,
+			row.keyPool,
+			row.drillStep,
+			row.passed,
+			formatFloat(r.Parameters.VerifyRate),
+			strconv.FormatUint(a.InvalidSignatures, 10),
+			strconv.FormatUint(a.Inconsistent, 10),
+			formatFloat(r.Parameters.SessionTimeout),
+			strconv.FormatUint(a.Timeouts, 10),
+			formatFloat(a.LostSeconds),
+			formatFloat(a.ErrorRate),
+			formatFailures(a.Failures),
+			formatFaultProfiles(r.FaultProfiles, r.StartTime),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
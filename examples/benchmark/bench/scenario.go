@@ -0,0 +1,464 @@
+package bench
+
+import (
+	"benchmark/random"
+	"benchmark/stats"
+	"benchmark/test"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"golang.org/x/exp/rand"
+	"golang.org/x/sync/errgroup"
+)
+
+// Scenario describes a mixed workload. The key pools are generated before the first phase, and the phases are run one
+// after another. In each phase, every client repeatedly picks one of the phase operations at random, according to the
+// operation weights.
+type Scenario struct {
+	KeyPools []KeyPool `json:"keyPools"`
+	Phases   []Phase   `json:"phases"`
+}
+
+type KeyPool struct {
+	Name      string `json:"name"`
+	Algorithm string `json:"algorithm"`
+	Size      int    `json:"size"`
+}
+
+type Phase struct {
+	Name       string              `json:"name"`
+	Duration   scenarioDuration    `json:"duration"`
+	Clients    int                 `json:"clients"`
+	Operations []WeightedOperation `json:"operations"`
+}
+
+type WeightedOperation struct {
+	Operation       string  `json:"operation"`
+	KeyPool         string  `json:"keyPool"`
+	Weight          float64 `json:"weight"`
+	PresigBatchSize uint64  `json:"presigBatchSize,omitempty"`
+}
+
+// A duration written as a string such as "90s" or "5m"
+type scenarioDuration time.Duration
+
+func (d *scenarioDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = scenarioDuration(v)
+	return nil
+}
+
+func (d scenarioDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Returns the total duration of the phases of a scenario
+func (s *Scenario) duration() time.Duration {
+	var d time.Duration
+	for _, phase := range s.Phases {
+		d += time.Duration(phase.Duration)
+	}
+	return d
+}
+
+func loadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	if err = json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+
+	if len(s.Phases) == 0 {
+		return nil, fmt.Errorf("no phases")
+	}
+	pools := map[string]bool{}
+	for _, p := range s.KeyPools {
+		if p.Name == "" || pools[p.Name] {
+			return nil, fmt.Errorf("missing or duplicate key pool name: %q", p.Name)
+		}
+		if _, err := ParseAlgorithm(p.Algorithm); err != nil {
+			return nil, fmt.Errorf("key pool %s: %w", p.Name, err)
+		}
+		if p.Size < 1 {
+			return nil, fmt.Errorf("key pool %s: invalid size: %d", p.Name, p.Size)
+		}
+		pools[p.Name] = true
+	}
+	for i, phase := range s.Phases {
+		if phase.Name == "" {
+			s.Phases[i].Name = fmt.Sprintf("phase%d", i)
+		}
+		if phase.Duration <= 0 {
+			return nil, fmt.Errorf("phase %s: invalid duration: %v", s.Phases[i].Name, time.Duration(phase.Duration))
+		}
+		if phase.Clients < 1 {
+			return nil, fmt.Errorf("phase %s: invalid clients: %d", s.Phases[i].Name, phase.Clients)
+		}
+		if len(phase.Operations) == 0 {
+			return nil, fmt.Errorf("phase %s: no operations", s.Phases[i].Name)
+		}
+		for _, op := range phase.Operations {
+			switch op.Operation {
+			case "sign", "getpub", "presigGen", "onlineSign":
+			default:
+				return nil, fmt.Errorf("phase %s: invalid operation: %s", s.Phases[i].Name, op.Operation)
+			}
+			if !pools[op.KeyPool] {
+				return nil, fmt.Errorf("phase %s: unknown key pool: %s", s.Phases[i].Name, op.KeyPool)
+			}
+			if op.Weight <= 0 {
+				return nil, fmt.Errorf("phase %s: invalid weight for %s: %v", s.Phases[i].Name, op.Operation, op.Weight)
+			}
+		}
+	}
+
+	return &s, nil
+}
+
+type ScenarioResult struct {
+	File   string        `json:"file"`
+	Phases []PhaseResult `json:"phases"`
+}
+
+type PhaseResult struct {
+	Name            string            `json:"name"`
+	Clients         int               `json:"clients"`
+	DurationSeconds float64           `json:"durationSeconds"`
+	ElapsedSeconds  float64           `json:"elapsedSeconds"`
+	Operations      []OperationResult `json:"operations"`
+}
+
+type OperationResult struct {
+	Operation   string         `json:"operation"`
+	KeyPool     string         `json:"keyPool,omitempty"`
+	Algorithm   string         `json:"algorithm"`
+	Weight      float64        `json:"weight,omitempty"`
+	Operations  uint64         `json:"operations"`
+	Errors      uint64         `json:"errors"`
+	ErrorRate   float64        `json:"errorRate"`
+	Failures    []FailureCount `json:"failures,omitempty"`
+	Timeouts    uint64         `json:"timeouts,omitempty"`
+	LostSeconds float64        `json:"lostSeconds,omitempty"`
+	// Scenario onlineSign operations that were not run because their key pool had no presignatures left
+	Exhausted    uint64          `json:"exhausted,omitempty"`
+	OpsPerSecond float64         `json:"opsPerSecond"`
+	Latency      *LatencySummary `json:"latency,omitempty"`
+}
+
+// The keys of a key pool, along with the presignatures generated for them during the scenario
+type keyPool struct {
+	KeyPool
+	algorithm Algorithm
+	keyIDs    []string
+
+	lock    sync.Mutex
+	presigs map[string][]string
+}
+
+func (p *keyPool) addPresigs(keyID string, presigIDs []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.presigs[keyID] = append(p.presigs[keyID], presigIDs...)
+}
+
+// Removes and returns an unused presignature for any key in the pool
+func (p *keyPool) takePresig() (keyID, presigID string, ok bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for keyID, presigIDs := range p.presigs {
+		if len(presigIDs) > 0 {
+			p.presigs[keyID] = presigIDs[1:]
+			return keyID, presigIDs[0], true
+		}
+	}
+	return "", "", false
+}
+
+// Returned by runScenarioOperation for an onlineSign operation on a key pool without presignatures
+var errNoPresignatures = errors.New("no presignatures available")
+
+// The wait of a scenario client after it found no presignatures for an onlineSign operation
+const presigWait = 10 * time.Millisecond
+
+// Counters of one phase operation, kept per client and merged when the phase ends
+type operationCounters struct {
+	operations uint64
+	errors     uint64
+	timeouts   uint64
+	lost       time.Duration // Time spent in failed operations, including those that timed out
+	exhausted  uint64
+	latency    *stats.Histogram
+	failures   failureCounts
+}
+
+// Counts a failed operation and the time it took, and reports whether it is the first failure of its category on its
+// node
+func (c *operationCounters) countFailure(err error, elapsed time.Duration) bool {
+	c.errors++
+	c.lost += elapsed
+	if isTimeout(err) {
+		c.timeouts++
+	}
+	return c.failures.add(err)
+}
+
+func (c *operationCounters) merge(other *operationCounters) {
+	c.operations += other.operations
+	c.errors += other.errors
+	c.timeouts += other.timeouts
+	c.lost += other.lost
+	c.exhausted += other.exhausted
+	c.latency.Merge(other.latency)
+	c.failures.merge(&other.failures)
+}
+
+// Returns the result of an operation with the merged counters, run for the given time
+func (c *operationCounters) result(operation, algorithm string, runSeconds float64) OperationResult {
+	return OperationResult{
+		Operation:    operation,
+		Algorithm:    algorithm,
+		Operations:   c.operations,
+		Errors:       c.errors,
+		ErrorRate:    failureRate(c.operations, c.errors),
+		Failures:     c.failures.list(),
+		Timeouts:     c.timeouts,
+		LostSeconds:  c.lost.Seconds(),
+		Exhausted:    c.exhausted,
+		OpsPerSecond: float64(c.operations) / runSeconds,
+		Latency:      newLatencySummary(c.latency),
+	}
+}
+
+func (b *Benchmark) benchmarkScenario() error {
+	pools := map[string]*keyPool{}
+	for _, p := range b.scenario.KeyPools {
+		algorithm, err := ParseAlgorithm(p.Algorithm)
+		if err != nil {
+			return err
+		}
+		pool := &keyPool{KeyPool: p, algorithm: algorithm, presigs: map[string][]string{}}
+		for i := 0; i < p.Size; i++ {
+			keyID, err := b.generateKey(algorithm)
+			if err != nil {
+				return fmt.Errorf("error generating key for key pool %s: %w", p.Name, err)
+			}
+			b.recordKey(keyID)
+			pool.keyIDs = append(pool.keyIDs, keyID)
+		}
+		pools[p.Name] = pool
+		b.println("Generated", p.Size, algorithm, "keys for key pool", p.Name)
+	}
+
+	for _, phase := range b.scenario.Phases {
+		if b.stopped() {
+			break
+		}
+		phaseResult, err := b.runPhase(phase, pools)
+		if err != nil {
+			return err
+		}
+		b.phaseResults = append(b.phaseResults, phaseResult)
+	}
+
+	return nil
+}
+
+func (b *Benchmark) runPhase(phase Phase, pools map[string]*keyPool) (PhaseResult, error) {
+	b.printf("Running phase %s with %d clients for %v\n", phase.Name, phase.Clients, time.Duration(phase.Duration))
+
+	var totalWeight float64
+	for _, op := range phase.Operations {
+		totalWeight += op.Weight
+	}
+
+	startTime := time.Now()
+	endTime := startTime.Add(time.Duration(phase.Duration))
+	clientCounters := make([][]operationCounters, phase.Clients)
+	var eg errgroup.Group
+	for i := 0; i < phase.Clients; i++ {
+		i := i
+		counters := make([]operationCounters, len(phase.Operations))
+		for j := range counters {
+			counters[j].latency = stats.NewHistogram()
+		}
+		clientCounters[i] = counters
+		eg.Go(func() error {
+			derivationPath := []uint32{1, 2, 3, 4, 5}
+			var failures int
+			for !b.done(endTime) {
+				// Pick an operation according to the weights
+				opIndex := 0
+				for w := rand.Float64() * totalWeight; opIndex < len(phase.Operations)-1; opIndex++ {
+					w -= phase.Operations[opIndex].Weight
+					if w < 0 {
+						break
+					}
+				}
+				op := phase.Operations[opIndex]
+
+				derivationPath[4]++
+				sessionStart := time.Now()
+				err := b.runScenarioOperation(op, pools[op.KeyPool], derivationPath)
+				algorithm := pools[op.KeyPool].algorithm.String()
+				if errors.Is(err, errNoPresignatures) {
+					// Not a failed session: the presigGen operations have not caught up with the onlineSign operations.
+					// The client waits a little for new presignatures rather than picking operations in a busy loop.
+					counters[opIndex].exhausted++
+					b.sleepUntil(time.Now().Add(presigWait))
+					continue
+				}
+				if err != nil {
+					if counters[opIndex].countFailure(err, time.Since(sessionStart)) || b.showProgress {
+						b.println("Scenario client", i, op.Operation, "error:", err)
+					}
+					b.emit(Event{Type: SessionFailed, Operation: op.Operation, Algorithm: algorithm, Client: i, Err: err})
+					failures++
+					b.backOff(failures)
+					continue
+				}
+				failures = 0
+				elapsed := time.Since(sessionStart)
+				counters[opIndex].latency.Record(elapsed)
+				counters[opIndex].operations++
+				if b.showProgress {
+					b.println("Scenario client", i, "completed", op.Operation, "on key pool", op.KeyPool)
+				}
+				b.emit(Event{Type: SessionDone, Operation: op.Operation, Algorithm: algorithm, Client: i, Latency: elapsed})
+
+				b.randomDelay()
+			}
+			b.emit(Event{Type: ClientStopped, Operation: "scenario", Client: i})
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return PhaseResult{}, err
+	}
+	elapsedSeconds := time.Since(startTime).Seconds()
+
+	result := PhaseResult{
+		Name:            phase.Name,
+		Clients:         phase.Clients,
+		DurationSeconds: time.Duration(phase.Duration).Seconds(),
+		ElapsedSeconds:  elapsedSeconds,
+	}
+	// A phase that was stopped early ran for less than its duration
+	runSeconds := result.DurationSeconds
+	if b.stopped() {
+		runSeconds = min(runSeconds, elapsedSeconds)
+	}
+	for j, op := range phase.Operations {
+		merged := operationCounters{latency: stats.NewHistogram()}
+		for _, counters := range clientCounters {
+			merged.merge(&counters[j])
+		}
+		opResult := merged.result(op.Operation, pools[op.KeyPool].algorithm.String(), runSeconds)
+		opResult.KeyPool = op.KeyPool
+		opResult.Weight = op.Weight
+		result.Operations = append(result.Operations, opResult)
+	}
+
+	return result, nil
+}
+
+func (b *Benchmark) runScenarioOperation(op WeightedOperation, pool *keyPool, derivationPath []uint32) error {
+	message := pool.algorithm.signInput()
+	keyID := pool.keyIDs[rand.Intn(len(pool.keyIDs))]
+
+	switch op.Operation {
+	case "sign":
+		sessionConfig, selectedClients := subset(b.clients, b.signers)
+		_, err := b.sign(pool.algorithm, sessionConfig, selectedClients, keyID, derivationPath, message)
+		return err
+	case "getpub":
+		_, err := b.derivedPublicKey(pool.algorithm, keyID, derivationPath)
+		return err
+	case "presigGen":
+		batchSize := op.PresigBatchSize
+		if batchSize == 0 {
+			batchSize = b.presigBatchSize
+		}
+		presigIDs, err := b.generatePresignatures(pool.algorithm, keyID, batchSize)
+		if err != nil {
+			return err
+		}
+		pool.addPresigs(keyID, presigIDs)
+		return nil
+	case "onlineSign":
+		keyID, presigID, ok := pool.takePresig()
+		if !ok {
+			return errNoPresignatures
+		}
+		_, err := b.signWithPresignature(pool.algorithm, keyID, presigID, derivationPath, message)
+		return err
+	default:
+		return fmt.Errorf("invalid operation: %s", op.Operation)
+	}
+}
+
+// Generates a single key with all MPC nodes, in a setup session
+func (b *Benchmark) generateKey(a Algorithm) (string, error) {
+	keyID := random.String(20)
+	sessionConfig := test.CreateSessionConfig(b.clients)
+	keyGenFunc := func(ctx context.Context, playerIndex int, client *tsm.Client) error {
+		var err error
+		if a.isECDSA() {
+			_, err = client.ECDSA().GenerateKey(ctx, sessionConfig, b.threshold, a.Curve, keyID)
+		} else {
+			_, err = client.Schnorr().GenerateKey(ctx, sessionConfig, b.threshold, a.Curve, keyID)
+		}
+		return err
+	}
+	if err := b.runSetupSession(b.clients, keyGenFunc); err != nil {
+		return "", err
+	}
+	return keyID, nil
+}
+
+func (p reportWriter) printScenario(r *ScenarioResult) {
+	p.println()
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "Phase\tOperation\tKey pool\tAlgorithm\tOperations\tErrors\tError rate\tOps/sec\tp50\tp99")
+	for _, phase := range r.Phases {
+		for _, op := range phase.Operations {
+			p50, p99 := "-", "-"
+			if op.Latency != nil {
+				p50 = fromMilliseconds(op.Latency.P50).String()
+				p99 = formatP99(op.Latency)
+			}
+			_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%.2f%%\t%.2f\t%s\t%s\n", phase.Name, op.Operation, op.KeyPool, op.Algorithm, op.Operations, op.Errors, 100*op.ErrorRate, op.OpsPerSecond, p50, p99)
+		}
+	}
+	_ = w.Flush()
+
+	for _, phase := range r.Phases {
+		for _, op := range phase.Operations {
+			if op.Timeouts > 0 {
+				p.printf("Phase %s %s on key pool %s: %d sessions timed out\n", phase.Name, op.Operation, op.KeyPool, op.Timeouts)
+			}
+			if op.LostSeconds > 0 {
+				p.printf("Phase %s %s on key pool %s: %v spent in failed sessions\n", phase.Name, op.Operation, op.KeyPool, seconds(op.LostSeconds).Round(time.Millisecond))
+			}
+			if op.Exhausted > 0 {
+				p.printf("Phase %s %s on key pool %s: %d times no presignature was available\n", phase.Name, op.Operation, op.KeyPool, op.Exhausted)
+			}
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package bench
+
+import (
+	"benchmark/stats"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// The counters of the clients of an operation are merged into its result, with the timeouts and the time lost to
+// failed sessions
+func TestOperationCounters(t *testing.T) {
+	clients := make([]operationCounters, 2)
+	for i := range clients {
+		clients[i].latency = stats.NewHistogram()
+	}
+	clients[0].latency.Record(10 * time.Millisecond)
+	clients[0].operations++
+	clients[0].countFailure(errors.New("failed"), 20*time.Millisecond)
+	clients[1].countFailure(fmt.Errorf("session timed out after 1s: %w", context.DeadlineExceeded), time.Second)
+	clients[1].exhausted += 3
+
+	merged := operationCounters{latency: stats.NewHistogram()}
+	for i := range clients {
+		merged.merge(&clients[i])
+	}
+	r := merged.result("sign", "ECDSA/secp256k1", 2)
+	if r.Operations != 1 || r.Errors != 2 || r.Timeouts != 1 || r.Exhausted != 3 || r.OpsPerSecond != 0.5 {
+		t.Errorf("result %+v", r)
+	}
+	if r.LostSeconds != 1.02 {
+		t.Errorf("%v seconds lost, want 1.02", r.LostSeconds)
+	}
+	if r.Latency == nil || r.Latency.Count != 1 {
+		t.Errorf("latency %+v, want one session", r.Latency)
+	}
+}
@@ -0,0 +1,84 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Returns the context of a single session: a child of the root context of the benchmark, with the session timeout if
+// one is set
+func (b *Benchmark) sessionContext() (context.Context, context.CancelFunc) {
+	if b.sessionTimeout > 0 {
+		return context.WithTimeout(b.ctx, b.sessionTimeout)
+	}
+	return context.WithCancel(b.ctx)
+}
+
+// Runs a session of the measured operation on the given clients. If a player fails, the calls of the other players are
+// cancelled.
+func (b *Benchmark) runSession(clients map[int]*tsm.Client, runFunc func(ctx context.Context, playerIndex int, client *tsm.Client) error) error {
+	_, err := runSessionWithResults(b, clients, func(ctx context.Context, playerIndex int, client *tsm.Client) (struct{}, error) {
+		return struct{}{}, runFunc(ctx, playerIndex, client)
+	})
+	return err
+}
+
+// Runs a session of the measured operation on the given clients like runSession, and returns the result of each
+// player. The timing of each player is recorded in the player timings of the benchmark.
+func runSessionWithResults[T any](b *Benchmark, clients map[int]*tsm.Client, runFunc func(ctx context.Context, playerIndex int, client *tsm.Client) (T, error)) (test.SessionResults[T], error) {
+	return runSessionRecording(b, clients, true, runFunc)
+}
+
+// Runs a session like runSession that sets up, checks or cleans up after the measured operation, such as generating
+// the benchmark keys or deleting keys. Its player timings are not recorded, so that they do not skew those of the
+// measured sessions.
+func (b *Benchmark) runSetupSession(clients map[int]*tsm.Client, runFunc func(ctx context.Context, playerIndex int, client *tsm.Client) error) error {
+	_, err := runSetupSessionWithResults(b, clients, func(ctx context.Context, playerIndex int, client *tsm.Client) (struct{}, error) {
+		return struct{}{}, runFunc(ctx, playerIndex, client)
+	})
+	return err
+}
+
+// Runs a session like runSetupSession, and returns the result of each player
+func runSetupSessionWithResults[T any](b *Benchmark, clients map[int]*tsm.Client, runFunc func(ctx context.Context, playerIndex int, client *tsm.Client) (T, error)) (test.SessionResults[T], error) {
+	return runSessionRecording(b, clients, false, runFunc)
+}
+
+func runSessionRecording[T any](b *Benchmark, clients map[int]*tsm.Client, recordTimings bool, runFunc func(ctx context.Context, playerIndex int, client *tsm.Client) (T, error)) (test.SessionResults[T], error) {
+	ctx, cancel := b.sessionContext()
+	defer cancel()
+	results, err := test.RunClientsWithResults(ctx, clients, runFunc)
+	if recordTimings {
+		spans := map[int]playerSpan{}
+		for playerIndex, r := range results {
+			spans[playerIndex] = playerSpan{start: r.Start, end: r.End}
+		}
+		b.playerTimings.record(spans, err == nil)
+	}
+	return results, b.sessionError(ctx, err)
+}
+
+// Marks the error of a session that ran out of time, so that it is counted as a timeout whatever the players
+// returned when their calls were cancelled
+func (b *Benchmark) sessionError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("session timed out after %v: %w: %w", b.sessionTimeout, context.DeadlineExceeded, err)
+	}
+	return err
+}
+
+// Reports whether a session failed because it ran out of time
+func isTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// Returns the time lost to aborted sessions of an algorithm
+func (a *algorithmState) lostTime() time.Duration {
+	return time.Duration(atomic.LoadInt64(&a.lost))
+}
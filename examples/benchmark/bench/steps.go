@@ -0,0 +1,63 @@
+package bench
+
+import (
+	"benchmark/stats"
+	"time"
+)
+
+// Counters of the steps of a multi-step operation, such as an export/import round trip, keyed by step. The counters
+// are kept per client and merged when the benchmark ends.
+type stepCounters map[string]*operationCounters
+
+func newStepCounters(steps []string) stepCounters {
+	counters := stepCounters{}
+	for _, step := range steps {
+		counters[step] = &operationCounters{latency: stats.NewHistogram()}
+	}
+	return counters
+}
+
+// Runs f and counts it as an operation of the counters. The counters are only used by a single client.
+func timeOperation(counters *operationCounters, f func() error) error {
+	start := time.Now()
+	if err := f(); err != nil {
+		counters.countFailure(err, time.Since(start))
+		return err
+	}
+	counters.latency.Record(time.Since(start))
+	counters.operations++
+	return nil
+}
+
+func (b *Benchmark) mergeStepCounters(algorithm string, steps []string, counters []stepCounters) []OperationResult {
+	if len(counters) == 0 {
+		return nil
+	}
+	var results []OperationResult
+	for _, step := range steps {
+		merged := operationCounters{latency: stats.NewHistogram()}
+		for _, c := range counters {
+			merged.merge(c[step])
+		}
+		results = append(results, merged.result(step, algorithm, b.runDuration().Seconds()))
+	}
+	return results
+}
+
+func (p reportWriter) printSteps(steps []OperationResult) {
+	for _, r := range steps {
+		p.printf("%s %s: %d (%.2f ops/sec ; %d failed sessions ; %.2f%% errors)\n", r.Algorithm, r.Operation, r.Operations, r.OpsPerSecond, r.Errors, 100*r.ErrorRate)
+		if r.Timeouts > 0 {
+			p.printf(" - %d sessions timed out\n", r.Timeouts)
+		}
+		if r.LostSeconds > 0 {
+			p.printf(" - %v spent in failed sessions\n", seconds(r.LostSeconds).Round(time.Millisecond))
+		}
+		if len(r.Failures) > 0 {
+			p.println(" - failures:", formatFailures(r.Failures))
+		}
+		if r.Latency != nil {
+			p.printf(" - latency: min %v ; p50 %v ; p99 %v ; max %v\n", fromMilliseconds(r.Latency.Min), fromMilliseconds(r.Latency.P50), fromMilliseconds(r.Latency.P99), fromMilliseconds(r.Latency.Max))
+		}
+	}
+}
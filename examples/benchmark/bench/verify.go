@@ -0,0 +1,122 @@
+package bench
+
+import (
+	"benchmark/test"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"golang.org/x/exp/rand"
+)
+
+// Returned, wrapped, when a signature produced by the cluster does not verify. Sessions failing with this error are
+// counted as invalid signatures rather than as errors.
+var errInvalidSignature = errors.New("invalid signature")
+
+// Signs with a key on the given clients, and returns the partial signature of each player
+func (b *Benchmark) sign(a Algorithm, sessionConfig *tsm.SessionConfig, clients map[int]*tsm.Client, keyID string, derivationPath []uint32, message []byte) (test.SessionResults[[]byte], error) {
+	return runSessionWithResults(b, clients, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]byte, error) {
+		if a.isECDSA() {
+			result, err := client.ECDSA().Sign(ctx, sessionConfig, keyID, derivationPath, message)
+			if err != nil {
+				return nil, err
+			}
+			return result.PartialSignature, nil
+		}
+		result, err := client.Schnorr().Sign(ctx, sessionConfig, keyID, derivationPath, message)
+		if err != nil {
+			return nil, err
+		}
+		return result.PartialSignature, nil
+	})
+}
+
+// Signs with a key and a presignature on all clients, and returns the partial signature of each player
+func (b *Benchmark) signWithPresignature(a Algorithm, keyID, presigID string, derivationPath []uint32, message []byte) (test.SessionResults[[]byte], error) {
+	return runSessionWithResults(b, b.clients, func(ctx context.Context, playerIndex int, client *tsm.Client) ([]byte, error) {
+		if a.isECDSA() {
+			result, err := client.ECDSA().SignWithPresignature(ctx, keyID, presigID, derivationPath, message)
+			if err != nil {
+				return nil, err
+			}
+			return result.PartialSignature, nil
+		}
+		result, err := client.Schnorr().SignWithPresignature(ctx, keyID, presigID, derivationPath, message)
+		if err != nil {
+			return nil, err
+		}
+		return result.PartialSignature, nil
+	})
+}
+
+// Reports whether the signature of the next session should be verified, according to verifyRate
+func (b *Benchmark) sampleVerify() bool {
+	return b.verifyRate > 0 && rand.Float64() < b.verifyRate
+}
+
+// Combines the partial signatures of a session and verifies the signature locally against the public key derived from
+// the key along derivationPath. The derived public key is read from a single player.
+func (b *Benchmark) verifySession(a Algorithm, keyID string, derivationPath []uint32, message []byte, partials test.SessionResults[[]byte]) error {
+	ctx, cancel := b.sessionContext()
+	defer cancel()
+	var publicKey []byte
+	var err error
+	for _, client := range b.clients {
+		if a.isECDSA() {
+			publicKey, err = client.ECDSA().PublicKey(ctx, keyID, derivationPath)
+		} else {
+			publicKey, err = client.Schnorr().PublicKey(ctx, keyID, derivationPath)
+		}
+		break
+	}
+	if err = b.sessionError(ctx, err); err != nil {
+		return fmt.Errorf("error getting public key for verification: %w", err)
+	}
+	return verifySignature(a, publicKey, message, partials)
+}
+
+// Combines partial signatures and verifies the signature against a JSON public key. A signature that cannot be
+// combined or does not verify is reported as errInvalidSignature.
+func verifySignature(a Algorithm, publicKey, message []byte, partials test.SessionResults[[]byte]) error {
+	if a.isECDSA() {
+		signature, err := test.CombineECDSASignatures(message, partials)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errInvalidSignature, err)
+		}
+		if err := tsm.ECDSAVerifySignature(publicKey, message, signature.ASN1()); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidSignature, err)
+		}
+		return nil
+	}
+	signature, err := test.CombineSchnorrSignatures(message, partials)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidSignature, err)
+	}
+	if err := tsm.SchnorrVerifySignature(publicKey, message, signature); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidSignature, err)
+	}
+	return nil
+}
+
+// Counts a failed session as an invalid signature, as a disagreement between the players, or as an error, and adds
+// the time the session took to the time lost to failed sessions. Sessions that ran out of time are also counted as
+// timeouts. The failure is also counted by category and node; countFailure reports whether it is the first failure of
+// its category on its node, which is printed even without -showProgress.
+func (a *algorithmState) countFailure(err error, elapsed time.Duration) bool {
+	atomic.AddInt64(&a.lost, int64(elapsed))
+	if isTimeout(err) {
+		atomic.AddUint64(&a.timeouts, 1)
+	}
+	switch {
+	case errors.Is(err, errInvalidSignature):
+		atomic.AddUint64(&a.invalid, 1)
+	case isInconsistent(err):
+		atomic.AddUint64(&a.inconsistent, 1)
+	default:
+		atomic.AddUint64(&a.errors, 1)
+	}
+	return a.failures.add(err)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"benchmark/bench"
+	"benchmark/flags"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func cleanupCommand(name string, args []string) {
+	options := bench.DefaultOptions()
+	options.Log = os.Stdout
+
+	var dryRun bool
+	var nodeURLs flags.URLArray
+
+	flagSet := flag.NewFlagSet(name, flag.ExitOnError)
+	config := addConfigFlags(flagSet)
+	config.ignoreUnknown = true
+	flagSet.Var(&nodeURLs, "node", "Specify an MPC node. Example: http://apikey@localhost:8080")
+	flagSet.StringVar(&options.KeyFile, "keyFile", "", "File with the IDs of the keys to delete, as written by run with -keyFile. Required")
+	flagSet.StringVar(&options.PresigDir, "presigDir", options.PresigDir, "Directory with the presig ID files written by operation presigGen")
+	flagSet.BoolVar(&dryRun, "dryRun", false, "List the keys and presignature files to delete without deleting them")
+	flagSet.BoolVar(&options.ShowProgress, "showProgress", false, "Print a line for each deleted key")
+	setUsage(flagSet, "[-config <file>] -node <url> -node <url> ... -keyFile <file> [flags]",
+		"Deletes the keys that run recorded in the key file, and the presignatures listed in the presig ID files,\n"+
+			"which are then removed. The key file is rewritten with the keys that could not be deleted, so cleanup can be run\n"+
+			"again. Give the same nodes as to run.")
+	_ = flagSet.Parse(args)
+	if flagSet.NArg() > 0 {
+		usageError(flagSet, "unexpected arguments:", strings.Join(flagSet.Args(), " "))
+	}
+
+	options.Nodes = applyConfig(flagSet, config, nodeURLs)
+	if options.KeyFile == "" {
+		usageError(flagSet, "-keyFile is required")
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	if err := bench.Cleanup(ctx, options, dryRun); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
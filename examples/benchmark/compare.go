@@ -0,0 +1,36 @@
+package main
+
+import (
+	"benchmark/bench"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func compareCommand(name string, args []string) {
+	flagSet := flag.NewFlagSet(name, flag.ExitOnError)
+	setUsage(flagSet, "<base.json> <candidate.json>",
+		"Compares two results written by run with -output in format json: lists the parameters that differ, and the\n"+
+			"change in throughput, error rate and p50 and p99 latency from the base to the candidate for each algorithm,\n"+
+			"operation, scenario phase and ramp step found in both.")
+	_ = flagSet.Parse(args)
+	if flagSet.NArg() != 2 {
+		usageError(flagSet, "expected two result files")
+	}
+
+	base, err := bench.ReadResult(flagSet.Arg(0))
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	candidate, err := bench.ReadResult(flagSet.Arg(1))
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Base:            ", flagSet.Arg(0))
+	fmt.Println("Candidate:       ", flagSet.Arg(1))
+	fmt.Println()
+	bench.WriteComparison(os.Stdout, base, candidate)
+}
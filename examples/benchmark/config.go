@@ -0,0 +1,321 @@
+package main
+
+import (
+	"benchmark/flags"
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+// Replaces the secrets in the printed configuration
+const redacted = "REDACTED"
+
+// The flags of a command that are not read from the configuration file; the nodes are under "nodes" instead
+var configOnlyFlags = []string{"config", "printConfig", "node"}
+
+// A configuration file is a JSON object with the MPC nodes under "nodes", and the other parameters under the names of
+// the flags of the command, such as "threshold": 1 or "duration": "30s". Flags that may be given more than once take a
+// list. Flags given on the command line override the file; -node flags replace all the nodes of the file.
+type configFile struct {
+	Nodes []nodeConfig
+	Flags map[string]json.RawMessage
+}
+
+// An MPC node in the configuration file. The player index defaults to the position of the node in the list. At most
+// one of the authentication settings may be set; an API key may also be given in the URL, as with -node.
+type nodeConfig struct {
+	Player          *int        `json:"player,omitempty"`
+	URL             string      `json:"url"`
+	APIKey          string      `json:"apiKey,omitempty"`
+	OIDCAccessToken string      `json:"oidcAccessToken,omitempty"`
+	MTLS            *mtlsConfig `json:"mtls,omitempty"`
+	TLS             *tlsConfig  `json:"tls,omitempty"`
+}
+
+// Client certificate for mTLS authentication
+type mtlsConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+// Validation of the certificate of an MPC node served over https
+type tlsConfig struct {
+	RootCAFile          string                 `json:"rootCAFile,omitempty"`          // Default is the system certificate store
+	ServerPublicKeyFile string                 `json:"serverPublicKeyFile,omitempty"` // PEM public key to pin; disables the other checks
+	OCSP                *tsm.OCSPConfiguration `json:"ocsp,omitempty"`
+}
+
+// The -config and -printConfig flags of a command
+type configFlags struct {
+	file  string
+	print bool
+
+	// Skip the settings that are not flags of the command, so that it can share the configuration file of run
+	ignoreUnknown bool
+}
+
+var errUnknownSetting = errors.New("unknown setting")
+
+func addConfigFlags(flagSet *flag.FlagSet) *configFlags {
+	c := &configFlags{}
+	flagSet.StringVar(&c.file, "config", "", "Read the nodes and the other flags from this JSON configuration file. Flags given on the command line override the file, and -node flags replace its nodes")
+	flagSet.BoolVar(&c.print, "printConfig", false, "Print the effective configuration, with the secrets redacted, and exit. The output can be used as a configuration file once the secrets are filled in")
+	return c
+}
+
+// Applies the configuration file, if any, to the flags that were not given on the command line, and returns the
+// configurations of the MPC nodes. The effective configuration is printed if it came from a file or -printConfig is
+// set; in the latter case the command exits.
+func applyConfig(flagSet *flag.FlagSet, c *configFlags, nodeURLs flags.URLArray) map[int]*tsm.Configuration {
+	var nodes []nodeConfig
+	for _, u := range nodeURLs {
+		nodes = append(nodes, nodeConfig{URL: u.String()})
+	}
+
+	if c.file != "" {
+		file, err := readConfigFile(c.file)
+		if err != nil {
+			usageError(flagSet, fmt.Sprintf("error reading configuration file %s: %s", c.file, err))
+		}
+		given := map[string]bool{}
+		flagSet.Visit(func(f *flag.Flag) { given[f.Name] = true })
+		for name, raw := range file.Flags {
+			if given[name] {
+				continue
+			}
+			err := setFlag(flagSet, name, raw)
+			if errors.Is(err, errUnknownSetting) && c.ignoreUnknown {
+				continue
+			}
+			if err != nil {
+				usageError(flagSet, fmt.Sprintf("invalid configuration file %s: %s", c.file, err))
+			}
+		}
+		if len(nodeURLs) == 0 {
+			nodes = file.Nodes
+		}
+	}
+
+	tsmConfigs, err := nodeConfigurations(nodes)
+	if err != nil {
+		usageError(flagSet, err)
+	}
+
+	if c.print || c.file != "" {
+		out, err := json.MarshalIndent(effectiveConfig(flagSet, nodes), "", "  ")
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if c.print {
+			fmt.Println(string(out))
+			os.Exit(0)
+		}
+		fmt.Printf("Configuration from %s, with the command-line flags applied:\n%s\n\n", c.file, out)
+	}
+
+	return tsmConfigs
+}
+
+func readConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file configFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&file.Flags); err != nil {
+		return nil, err
+	}
+	if raw, found := file.Flags["nodes"]; found {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&file.Nodes); err != nil {
+			return nil, fmt.Errorf("invalid nodes: %w", err)
+		}
+		delete(file.Flags, "nodes")
+	}
+	return &file, nil
+}
+
+// Sets a flag from its value in the configuration file: a string, a number, a boolean, or a list of these for a flag
+// that may be given more than once
+func setFlag(flagSet *flag.FlagSet, name string, raw json.RawMessage) error {
+	if flagSet.Lookup(name) == nil || slices.Contains(configOnlyFlags, name) {
+		return fmt.Errorf("%w: %s", errUnknownSetting, name)
+	}
+	values := []json.RawMessage{raw}
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("[")) {
+		if err := json.Unmarshal(raw, &values); err != nil {
+			return fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+	}
+	for _, value := range values {
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			var scalar any
+			if err := json.Unmarshal(value, &scalar); err != nil {
+				return fmt.Errorf("invalid value of %s: %w", name, err)
+			}
+			switch scalar.(type) {
+			case float64, bool:
+				s = string(bytes.TrimSpace(value))
+			default:
+				return fmt.Errorf("invalid value of %s: %s", name, value)
+			}
+		}
+		if err := flagSet.Set(name, s); err != nil {
+			return fmt.Errorf("invalid value of %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Returns the configuration file that reproduces the flags and nodes, with the secrets redacted. Lists that are
+// empty, such as -clients, are left out.
+func effectiveConfig(flagSet *flag.FlagSet, nodes []nodeConfig) map[string]any {
+	config := map[string]any{}
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if slices.Contains(configOnlyFlags, f.Name) {
+			return
+		}
+		// Durations are written like on the command line; other flags of basic types keep their JSON type
+		var value any = f.Value.String()
+		if getter, ok := f.Value.(flag.Getter); ok {
+			if _, isDuration := getter.Get().(time.Duration); !isDuration {
+				value = getter.Get()
+			}
+		} else if value == "" {
+			return
+		}
+		config[f.Name] = value
+	})
+
+	var redactedNodes []nodeConfig
+	for i, n := range nodes {
+		if n.Player == nil {
+			player := i
+			n.Player = &player
+		}
+		if u, err := url.Parse(n.URL); err == nil && u.User != nil {
+			if n.APIKey == "" {
+				n.APIKey = u.User.Username()
+			}
+			u.User = nil
+			n.URL = u.String()
+		}
+		if n.APIKey != "" {
+			n.APIKey = redacted
+		}
+		if n.OIDCAccessToken != "" {
+			n.OIDCAccessToken = redacted
+		}
+		redactedNodes = append(redactedNodes, n)
+	}
+	config["nodes"] = redactedNodes
+	return config
+}
+
+// Returns the configurations of the MPC nodes by player index
+func nodeConfigurations(nodes []nodeConfig) (map[int]*tsm.Configuration, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no MPC nodes given")
+	}
+	tsmConfigs := map[int]*tsm.Configuration{}
+	for i, n := range nodes {
+		player := i
+		if n.Player != nil {
+			player = *n.Player
+		}
+		if tsmConfigs[player] != nil {
+			return nil, fmt.Errorf("more than one MPC node for player %d", player)
+		}
+		tsmConfig, err := n.tsmConfiguration()
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration of MPC node %d: %w", player, err)
+		}
+		tsmConfigs[player] = tsmConfig
+	}
+	return tsmConfigs, nil
+}
+
+func (n nodeConfig) tsmConfiguration() (*tsm.Configuration, error) {
+	u, err := url.Parse(n.URL)
+	if err != nil {
+		return nil, err
+	}
+	scheme, host, port, path, user, err := parseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	tsmConfig := &tsm.Configuration{URL: fmt.Sprintf("%s://%s:%s%s", scheme, host, port, path)}
+
+	apiKey := n.APIKey
+	if user != "" {
+		if apiKey != "" {
+			return nil, fmt.Errorf("API key given both in the URL and in apiKey")
+		}
+		apiKey = user
+	}
+	var authentications int
+	if apiKey != "" {
+		tsmConfig = tsmConfig.WithAPIKeyAuthentication(apiKey)
+		authentications++
+	}
+	if n.OIDCAccessToken != "" {
+		tsmConfig = tsmConfig.WithOIDCAccessTokenAuthentication(n.OIDCAccessToken)
+		authentications++
+	}
+	if n.MTLS != nil {
+		if scheme != "https" {
+			return nil, fmt.Errorf("mTLS authentication requires https")
+		}
+		tsmConfig = tsmConfig.WithMTLSAuthentication(n.MTLS.KeyFile, n.MTLS.CertFile, nil)
+		authentications++
+	}
+	if authentications > 1 {
+		return nil, fmt.Errorf("more than one authentication method")
+	}
+
+	if n.TLS != nil {
+		if scheme != "https" {
+			return nil, fmt.Errorf("TLS settings require https")
+		}
+		if n.TLS.RootCAFile != "" {
+			tsmConfig = tsmConfig.WithRootCAFile(n.TLS.RootCAFile)
+		}
+		if n.TLS.ServerPublicKeyFile != "" {
+			publicKey, err := readPublicKey(n.TLS.ServerPublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("invalid server public key: %w", err)
+			}
+			tsmConfig = tsmConfig.WithPublicKeyPinning(publicKey)
+		}
+		if n.TLS.OCSP != nil {
+			tsmConfig = tsmConfig.WithOCSPValidation(n.TLS.OCSP)
+		}
+	}
+	return tsmConfig, nil
+}
+
+// Reads a PEM public key, and returns it in PKIX, ASN.1 DER form
+func readPublicKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("no PEM public key in %s", path)
+	}
+	return block.Bytes, nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"benchmark/flags"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Returns a flag set with flags of each kind that a configuration file can set
+func newTestFlagSet() (*flag.FlagSet, *configFlags) {
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	config := addConfigFlags(flagSet)
+	var nodeURLs flags.URLArray
+	var clients clientsFlag
+	flagSet.Var(&nodeURLs, "node", "")
+	flagSet.Var(&clients, "clients", "")
+	flagSet.String("operation", "sign", "")
+	flagSet.Int("threshold", 0, "")
+	flagSet.Duration("duration", 30*time.Second, "")
+	flagSet.Float64("verifyRate", 0, "")
+	flagSet.Uint64("presigBatchSize", 5, "")
+	flagSet.Bool("showProgress", false, "")
+	return flagSet, config
+}
+
+func TestReadConfigFile(t *testing.T) {
+	file, err := readConfigFile("configs/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(file.Nodes) != 3 {
+		t.Fatalf("%d nodes, want 3", len(file.Nodes))
+	}
+	if n := file.Nodes[2]; n.MTLS == nil || n.MTLS.CertFile != "client.crt" || n.TLS == nil || n.TLS.ServerPublicKeyFile != "tsm2.pub.pem" {
+		t.Errorf("node 2: %+v", n)
+	}
+	if _, found := file.Flags["nodes"]; found {
+		t.Errorf("nodes left in the flags")
+	}
+	if string(file.Flags["threshold"]) != "1" {
+		t.Errorf("threshold %s, want 1", file.Flags["threshold"])
+	}
+
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	if err := os.WriteFile(invalid, []byte(`{"nodes": [{"url": "http://localhost", "apiToken": "typo"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfigFile(invalid); err == nil {
+		t.Errorf("unknown node setting accepted")
+	}
+}
+
+func TestSetFlag(t *testing.T) {
+	tests := []struct {
+		name, value string
+		want        string
+		wantErr     error
+	}{
+		{name: "operation", value: `"keygen"`, want: "keygen"},
+		{name: "threshold", value: `2`, want: "2"},
+		{name: "verifyRate", value: `0.25`, want: "0.25"},
+		{name: "presigBatchSize", value: ` 25 `, want: "25"},
+		{name: "showProgress", value: `true`, want: "true"},
+		{name: "duration", value: `"90s"`, want: "1m30s"},
+		{name: "clients", value: `["ECDSA/P-256=2", "Ed25519=1"]`, want: "ECDSA/P-256=2,Schnorr/Ed25519=1"},
+		{name: "noSuchFlag", value: `1`, wantErr: errUnknownSetting},
+		{name: "node", value: `"http://localhost"`, wantErr: errUnknownSetting},
+		{name: "config", value: `"other.json"`, wantErr: errUnknownSetting},
+		{name: "printConfig", value: `true`, wantErr: errUnknownSetting},
+		{name: "threshold", value: `"two"`},
+		{name: "threshold", value: `{"value": 2}`},
+		{name: "threshold", value: `null`},
+		{name: "duration", value: `30`},
+		{name: "clients", value: `["ECDSA/P-256"]`},
+	}
+	for _, tt := range tests {
+		flagSet, _ := newTestFlagSet()
+		err := setFlag(flagSet, tt.name, json.RawMessage(tt.value))
+		if tt.want == "" {
+			if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("setFlag(%s, %s) = %v, want error %v", tt.name, tt.value, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("setFlag(%s, %s) failed: %v", tt.name, tt.value, err)
+			continue
+		}
+		if got := flagSet.Lookup(tt.name).Value.String(); got != tt.want {
+			t.Errorf("setFlag(%s, %s) set %s, want %s", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+// The printed configuration can be used as a configuration file, and holds no secrets
+func TestEffectiveConfig(t *testing.T) {
+	flagSet, _ := newTestFlagSet()
+	if err := flagSet.Parse([]string{"-threshold", "1", "-duration", "1m", "-clients", "ECDSA=2"}); err != nil {
+		t.Fatal(err)
+	}
+	player := 5
+	nodes := []nodeConfig{
+		{URL: "http://secretkey0@localhost:8500/tsm0"},
+		{URL: "https://localhost:8501", APIKey: "secretkey1"},
+		{Player: &player, URL: "https://localhost:8502", OIDCAccessToken: "secrettoken"},
+	}
+	config := effectiveConfig(flagSet, nodes)
+	out, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "secret") {
+		t.Errorf("secrets in the printed configuration: %s", out)
+	}
+
+	// Read the printed configuration back as a configuration file
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, out, 0644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("printed configuration cannot be read back: %v", err)
+	}
+	wantNodes := []struct {
+		player             int
+		url, apiKey, token string
+	}{
+		{0, "http://localhost:8500/tsm0", redacted, ""},
+		{1, "https://localhost:8501", redacted, ""},
+		{5, "https://localhost:8502", "", redacted},
+	}
+	if len(file.Nodes) != len(wantNodes) {
+		t.Fatalf("%d nodes, want %d", len(file.Nodes), len(wantNodes))
+	}
+	for i, want := range wantNodes {
+		n := file.Nodes[i]
+		if n.Player == nil || *n.Player != want.player || n.URL != want.url || n.APIKey != want.apiKey || n.OIDCAccessToken != want.token {
+			t.Errorf("node %d: %+v, want %+v", i, n, want)
+		}
+	}
+	for name, want := range map[string]string{"threshold": "1", "duration": `"1m0s"`, "operation": `"sign"`, "showProgress": "false", "clients": `"ECDSA/secp256k1=2"`} {
+		if got := string(file.Flags[name]); got != want {
+			t.Errorf("%s written as %s, want %s", name, got, want)
+		}
+	}
+	for _, name := range configOnlyFlags {
+		if _, found := file.Flags[name]; found {
+			t.Errorf("%s written to the configuration", name)
+		}
+	}
+
+	// Applying the printed configuration restores the flags
+	applied, _ := newTestFlagSet()
+	for name, raw := range file.Flags {
+		if err := setFlag(applied, name, raw); err != nil {
+			t.Errorf("setting %s: %v", name, err)
+		}
+	}
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if got := applied.Lookup(f.Name).Value.String(); got != f.Value.String() {
+			t.Errorf("%s restored as %s, want %s", f.Name, got, f.Value.String())
+		}
+	})
+}
+
+func TestNodeConfigurations(t *testing.T) {
+	one, zero := 1, 0
+	tests := []struct {
+		name    string
+		nodes   []nodeConfig
+		players []int
+		wantErr bool
+	}{
+		{name: "positions", nodes: []nodeConfig{{URL: "http://apikey0@localhost:8500"}, {URL: "http://localhost:8501", APIKey: "apikey1"}}, players: []int{0, 1}},
+		{name: "players", nodes: []nodeConfig{{Player: &one, URL: "http://localhost:8501"}, {Player: &zero, URL: "http://localhost:8500"}}, players: []int{0, 1}},
+		{name: "mTLS", nodes: []nodeConfig{{URL: "https://localhost:8500", MTLS: &mtlsConfig{CertFile: "client.crt", KeyFile: "client.key"}}}, players: []int{0}},
+		{name: "no nodes", wantErr: true},
+		{name: "duplicate player", nodes: []nodeConfig{{URL: "http://localhost:8500"}, {Player: &zero, URL: "http://localhost:8501"}}, wantErr: true},
+		{name: "API key twice", nodes: []nodeConfig{{URL: "http://apikey0@localhost:8500", APIKey: "apikey0"}}, wantErr: true},
+		{name: "two authentications", nodes: []nodeConfig{{URL: "https://localhost:8500", APIKey: "apikey0", OIDCAccessToken: "token"}}, wantErr: true},
+		{name: "mTLS over http", nodes: []nodeConfig{{URL: "http://localhost:8500", MTLS: &mtlsConfig{CertFile: "client.crt", KeyFile: "client.key"}}}, wantErr: true},
+		{name: "TLS over http", nodes: []nodeConfig{{URL: "http://localhost:8500", TLS: &tlsConfig{RootCAFile: "ca.pem"}}}, wantErr: true},
+		{name: "missing server public key", nodes: []nodeConfig{{URL: "https://localhost:8500", TLS: &tlsConfig{ServerPublicKeyFile: "missing.pem"}}}, wantErr: true},
+		{name: "invalid scheme", nodes: []nodeConfig{{URL: "ftp://localhost:8500"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		configs, err := nodeConfigurations(tt.nodes)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: succeeded, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(configs) != len(tt.players) {
+			t.Errorf("%s: %d configurations, want %d", tt.name, len(configs), len(tt.players))
+		}
+		for _, player := range tt.players {
+			if configs[player] == nil {
+				t.Errorf("%s: no configuration for player %d", tt.name, player)
+			}
+		}
+	}
+
+	// The API key is taken out of the URL
+	configs, err := nodeConfigurations([]nodeConfig{{URL: "http://apikey0@localhost:8500/tsm0"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if configs[0].URL != "http://localhost:8500/tsm0" {
+		t.Errorf("URL %s, want http://localhost:8500/tsm0", configs[0].URL)
+	}
+}
@@ -0,0 +1,101 @@
+// Command faultproxy runs a reverse proxy in front of each MPC node, and injects latency, jitter, dropped and reset
+// connections and error responses into the requests to the nodes, according to a schedule of fault profiles. Point the
+// benchmark at the proxies instead of the nodes, and pass it the control address with -faultProxy to label the
+// results with the fault profiles that were active.
+package main
+
+import (
+	"benchmark/faults"
+	"benchmark/flags"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func main() {
+	if err := run(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var nodeURLs flags.URLArray
+	var listen, control, scheduleFile string
+	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flagSet.Var(&nodeURLs, "node", "URL of an MPC node to proxy, in the same order as the -node flags of the benchmark. Example: http://localhost:8080")
+	flagSet.StringVar(&listen, "listen", "127.0.0.1:8500", "Address of the proxy of the first node; the proxy of node i listens on the port after the proxy of node i-1")
+	flagSet.StringVar(&control, "control", "127.0.0.1:8499", "Address of the control endpoint, which reports and switches the active fault profile")
+	flagSet.StringVar(&scheduleFile, "schedule", "", "JSON file with the fault profiles and the schedule for switching between them. Without a schedule, no faults are injected until a profile is switched to via the control endpoint")
+	if err := flagSet.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if len(nodeURLs) == 0 {
+		flagSet.Usage()
+		os.Exit(1)
+	}
+
+	schedule := &faults.Schedule{}
+	if scheduleFile != "" {
+		var err error
+		schedule, err = faults.LoadSchedule(scheduleFile)
+		if err != nil {
+			return fmt.Errorf("invalid schedule file %s: %w", scheduleFile, err)
+		}
+	}
+
+	host, portString, err := net.SplitHostPort(listen)
+	if err != nil {
+		return fmt.Errorf("invalid listen address: %w", err)
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return fmt.Errorf("invalid listen port: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	controller := faults.NewController(schedule)
+	servers := []*http.Server{{Addr: control, Handler: controller}}
+	fmt.Println("Control endpoint:", "http://"+control)
+	for i, nodeURL := range nodeURLs {
+		target := *nodeURL
+		target.User = nil
+		addr := net.JoinHostPort(host, strconv.Itoa(port+i))
+		servers = append(servers, &http.Server{Addr: addr, Handler: faults.NewProxy(i, &target, controller)})
+		fmt.Printf("Node %d: http://%s -> %s\n", i, addr, target.String())
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, server := range servers {
+		server := server
+		eg.Go(func() error {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		})
+	}
+	eg.Go(func() error {
+		controller.RunSchedule(ctx, func(name string) {
+			fmt.Println(time.Now().Format(time.TimeOnly), "switched to fault profile", name)
+		})
+		<-ctx.Done()
+		for _, server := range servers {
+			_ = server.Close()
+		}
+		return nil
+	})
+	return eg.Wait()
+}
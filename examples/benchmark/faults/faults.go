@@ -0,0 +1,135 @@
+package faults
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// Fault describes the faults injected into the requests to one node. The probabilities are per request, and their
+// sum must be at most 1.
+type Fault struct {
+	// Added to every request before it is forwarded, plus a uniformly random delay of up to Jitter
+	Latency Duration `json:"latency,omitempty"`
+	Jitter  Duration `json:"jitter,omitempty"`
+	// Probability that the connection is closed without a response
+	Drop float64 `json:"drop,omitempty"`
+	// Probability that the connection is reset
+	Reset float64 `json:"reset,omitempty"`
+	// Probability that the request is answered with ErrorStatus instead of being forwarded
+	Error       float64 `json:"error,omitempty"`
+	ErrorStatus int     `json:"errorStatus,omitempty"`
+}
+
+// Profile holds the faults of each node, by node index. Nodes without faults are left out.
+type Profile map[int]Fault
+
+// Step switches to a profile at a time relative to the start of the schedule
+type Step struct {
+	At      Duration `json:"at"`
+	Profile string   `json:"profile"`
+}
+
+// Schedule is a set of named fault profiles, and when to switch between them. Without steps, the profile named
+// "baseline" is active from the start, or no faults if there is no such profile.
+type Schedule struct {
+	Profiles map[string]Profile `json:"profiles"`
+	Steps    []Step             `json:"schedule"`
+}
+
+// ProfileChange records that a profile became active
+type ProfileChange struct {
+	Profile string    `json:"profile"`
+	Time    time.Time `json:"time"`
+}
+
+// A duration written as a string such as "200ms" or "5m"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Schedule
+	if err = json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schedule) validate() error {
+	for name, profile := range s.Profiles {
+		for node, fault := range profile {
+			if err := fault.validate(); err != nil {
+				return fmt.Errorf("profile %s, node %d: %w", name, node, err)
+			}
+		}
+	}
+	for _, step := range s.Steps {
+		if _, ok := s.Profiles[step.Profile]; !ok {
+			return fmt.Errorf("unknown profile in schedule: %s", step.Profile)
+		}
+		if step.At < 0 {
+			return fmt.Errorf("invalid schedule time for profile %s: %v", step.Profile, time.Duration(step.At))
+		}
+	}
+	sort.SliceStable(s.Steps, func(i, j int) bool { return s.Steps[i].At < s.Steps[j].At })
+	return nil
+}
+
+func (f Fault) validate() error {
+	if f.Latency < 0 || f.Jitter < 0 {
+		return fmt.Errorf("invalid latency or jitter")
+	}
+	for _, p := range []float64{f.Drop, f.Reset, f.Error} {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("invalid probability: %v", p)
+		}
+	}
+	if f.Drop+f.Reset+f.Error > 1 {
+		return fmt.Errorf("drop, reset and error probabilities add up to more than 1")
+	}
+	if f.ErrorStatus != 0 && (f.ErrorStatus < 400 || f.ErrorStatus > 599) {
+		return fmt.Errorf("invalid error status: %d", f.ErrorStatus)
+	}
+	return nil
+}
+
+// ActiveDuring returns the profile changes that were active at some point between start and end: the last change
+// before start, and the changes between start and end
+func ActiveDuring(history []ProfileChange, start, end time.Time) []ProfileChange {
+	var active []ProfileChange
+	for i, change := range history {
+		if change.Time.After(end) {
+			break
+		}
+		if i+1 < len(history) && !history[i+1].Time.After(start) {
+			continue
+		}
+		active = append(active, change)
+	}
+	return active
+}
@@ -0,0 +1,193 @@
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/rand"
+)
+
+// Controller holds the active fault profile of the proxies, and serves the control endpoint:
+//
+//	GET  /profile            returns the active profile change
+//	POST /profile?name=<n>   switches to the profile named n
+//	GET  /history            returns all profile changes, oldest first
+type Controller struct {
+	schedule *Schedule
+
+	lock    sync.Mutex
+	active  Profile
+	history []ProfileChange
+}
+
+func NewController(schedule *Schedule) *Controller {
+	c := &Controller{schedule: schedule}
+	if _, ok := schedule.Profiles["baseline"]; ok {
+		_ = c.Switch("baseline")
+	} else {
+		c.history = append(c.history, ProfileChange{Profile: "none", Time: time.Now()})
+	}
+	return c
+}
+
+// Switch makes the named profile active. Switching to the active profile is not recorded as a change.
+func (c *Controller) Switch(name string) error {
+	profile, ok := c.schedule.Profiles[name]
+	if !ok {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.active = profile
+	if len(c.history) == 0 || c.history[len(c.history)-1].Profile != name {
+		c.history = append(c.history, ProfileChange{Profile: name, Time: time.Now()})
+	}
+	return nil
+}
+
+// RunSchedule switches profiles according to the schedule, relative to now, until the schedule is done or ctx is
+// cancelled
+func (c *Controller) RunSchedule(ctx context.Context, onSwitch func(name string)) {
+	start := time.Now()
+	for _, step := range c.schedule.Steps {
+		timer := time.NewTimer(time.Until(start.Add(time.Duration(step.At))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		_ = c.Switch(step.Profile)
+		if onSwitch != nil {
+			onSwitch(step.Profile)
+		}
+	}
+}
+
+// History returns all profile changes, oldest first
+func (c *Controller) History() []ProfileChange {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]ProfileChange(nil), c.history...)
+}
+
+func (c *Controller) fault(node int) Fault {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.active[node]
+}
+
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/profile" && r.Method == http.MethodGet:
+		history := c.History()
+		writeJSON(w, history[len(history)-1])
+	case r.URL.Path == "/profile" && r.Method == http.MethodPost:
+		if err := c.Switch(r.URL.Query().Get("name")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		history := c.History()
+		writeJSON(w, history[len(history)-1])
+	case r.URL.Path == "/history" && r.Method == http.MethodGet:
+		writeJSON(w, c.History())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// Proxy forwards requests to one node, injecting the faults of the node in the active profile of the controller
+type Proxy struct {
+	node       int
+	controller *Controller
+	proxy      *httputil.ReverseProxy
+}
+
+func NewProxy(node int, target *url.URL, controller *Controller) *Proxy {
+	return &Proxy{node: node, controller: controller, proxy: httputil.NewSingleHostReverseProxy(target)}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fault := p.controller.fault(p.node)
+
+	delay := time.Duration(fault.Latency)
+	if fault.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(fault.Jitter)))
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	roll := rand.Float64()
+	switch {
+	case roll < fault.Drop:
+		closeConnection(w, false)
+	case roll < fault.Drop+fault.Reset:
+		closeConnection(w, true)
+	case roll < fault.Drop+fault.Reset+fault.Error:
+		status := fault.ErrorStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, "fault injected by proxy", status)
+	default:
+		p.proxy.ServeHTTP(w, r)
+	}
+}
+
+// Closes the connection of a request without a response. With reset, the connection is reset rather than closed
+// normally.
+func closeConnection(w http.ResponseWriter, reset bool) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "fault injected by proxy", http.StatusBadGateway)
+		return
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok && reset {
+		_ = tcpConn.SetLinger(0)
+	}
+	_ = conn.Close()
+}
+
+// FetchHistory returns the profile changes from the control endpoint of a fault proxy
+func FetchHistory(ctx context.Context, controlURL string) ([]ProfileChange, error) {
+	u, err := url.JoinPath(controlURL, "history")
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fault proxy returned %s", response.Status)
+	}
+	var history []ProfileChange
+	if err := json.NewDecoder(response.Body).Decode(&history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
@@ -0,0 +1,27 @@
+// Package flags provides the flag types shared by the benchmark commands
+package flags
+
+import (
+	"net/url"
+	"strings"
+)
+
+// URLArray is a flag that can be given more than once, such as the -node flag with the URL of each MPC node
+type URLArray []*url.URL
+
+func (s *URLArray) String() string {
+	var x []string
+	for i := range *s {
+		x = append(x, (*s)[i].String())
+	}
+	return strings.Join(x, " ")
+}
+
+func (s *URLArray) Set(v string) error {
+	u, err := url.Parse(v)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, u)
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"benchmark/bench"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		in                             string
+		scheme, host, port, path, user string
+		wantErr                        bool
+	}{
+		{in: "http://localhost", scheme: "http", host: "localhost", port: "80", path: "/"},
+		{in: "https://tsm.example.com", scheme: "https", host: "tsm.example.com", port: "443", path: "/"},
+		{in: "HTTP://apikey0@127.0.0.1:8500", scheme: "http", host: "127.0.0.1", port: "8500", path: "/", user: "apikey0"},
+		{in: "https://apikey@localhost:80/tsm0", scheme: "https", host: "localhost", port: "80", path: "/tsm0", user: "apikey"},
+		{in: "http://[::1]:8080/a%20b", scheme: "http", host: "::1", port: "8080", path: "/a%20b"},
+		{in: "localhost:8080", wantErr: true},
+		{in: "//localhost:8080", wantErr: true},
+		{in: "ftp://localhost", wantErr: true},
+		{in: "http://", wantErr: true},
+		{in: "http://apikey@:8080", wantErr: true},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.in)
+		if err != nil {
+			t.Fatalf("url.Parse(%q): %v", tt.in, err)
+		}
+		scheme, host, port, path, user, err := parseURL(u)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseURL(%q) succeeded, want an error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseURL(%q) failed: %v", tt.in, err)
+			continue
+		}
+		got := []string{scheme, host, port, path, user}
+		want := []string{tt.scheme, tt.host, tt.port, tt.path, tt.user}
+		if !slices.Equal(got, want) {
+			t.Errorf("parseURL(%q) = %q, want %q", tt.in, got, want)
+		}
+	}
+}
+
+func TestClientsFlag(t *testing.T) {
+	var f clientsFlag
+	if err := f.Set("ECDSA/P-256=10,Schnorr/BIP-340=5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Set("Ed25519=1"); err != nil {
+		t.Fatal(err)
+	}
+	want := clientsFlag{
+		{Algorithm: bench.Algorithm{Scheme: "ECDSA", Curve: "P-256"}, Clients: 10},
+		{Algorithm: bench.Algorithm{Scheme: "Schnorr", Curve: "BIP-340"}, Clients: 5},
+		{Algorithm: bench.Algorithm{Scheme: "Schnorr", Curve: "Ed25519"}, Clients: 1},
+	}
+	if !slices.Equal(f, want) {
+		t.Errorf("clients %v, want %v", f, want)
+	}
+	if f.String() != "ECDSA/P-256=10,Schnorr/BIP-340=5,Schnorr/Ed25519=1" {
+		t.Errorf("clients written as %s", f.String())
+	}
+
+	for _, invalid := range []string{"ECDSA/P-256", "ECDSA/P-256=0", "ECDSA/P-256=x", "RSA=1", "ECDSA=1,"} {
+		var f clientsFlag
+		if err := f.Set(invalid); err == nil {
+			t.Errorf("clients %q accepted", invalid)
+		}
+	}
+}
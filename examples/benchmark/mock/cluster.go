@@ -0,0 +1,37 @@
+package mock
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+)
+
+// Cluster is a set of mock nodes, each served on its own local port
+type Cluster struct {
+	servers []*httptest.Server
+}
+
+// StartCluster starts the given number of mock nodes on local ports, all with the same config. The cluster must be
+// closed when done.
+func StartCluster(nodes int, config Config) *Cluster {
+	c := &Cluster{}
+	for i := 0; i < nodes; i++ {
+		c.servers = append(c.servers, httptest.NewServer(NewNode(i, config)))
+	}
+	return c
+}
+
+// NodeURLs returns the URLs of the nodes in the form of the -node flags of the benchmark, with an API key as user
+func (c *Cluster) NodeURLs() []string {
+	var urls []string
+	for i, server := range c.servers {
+		urls = append(urls, strings.Replace(server.URL, "://", fmt.Sprintf("://apikey%d@", i), 1))
+	}
+	return urls
+}
+
+func (c *Cluster) Close() {
+	for _, server := range c.servers {
+		server.Close()
+	}
+}
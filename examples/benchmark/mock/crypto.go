@@ -0,0 +1,213 @@
+package mock
+
+import (
+	"crypto/elliptic"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"slices"
+
+	"filippo.io/edwards25519"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+const (
+	schemeECDSA   = "ECDSA"
+	schemeSchnorr = "Schnorr"
+
+	// The only Schnorr variant of the mock nodes, and the name of its curve
+	schnorrEd25519 = "Ed25519"
+	ed25519Curve   = "ED-25519"
+)
+
+var ecdsaCurves = map[string]elliptic.Curve{
+	"secp256k1": secp256k1.S256(),
+	"P-224":     elliptic.P224(),
+	"P-256":     elliptic.P256(),
+	"P-384":     elliptic.P384(),
+	"P-521":     elliptic.P521(),
+}
+
+// The order of the group of Ed25519
+var ed25519Order, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+
+func checkCurve(scheme, curve string) error {
+	if scheme == schemeECDSA && ecdsaCurves[curve] != nil || scheme == schemeSchnorr && curve == schnorrEd25519 {
+		return nil
+	}
+	return errorf(http.StatusBadRequest, "mock node does not support %s keys on %s", scheme, curve)
+}
+
+// Returns the SHA-512 hash of a label and a list of values. The values are length-prefixed, so that different lists
+// do not hash alike.
+func digest(label string, values ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte(label))
+	for _, v := range values {
+		h.Write(binary.BigEndian.AppendUint64(nil, uint64(len(v))))
+		h.Write(v)
+	}
+	return h.Sum(nil)
+}
+
+// Returns an ID that all nodes derive alike from the same values. IDs are 28 hex characters, the longest key ID the
+// SDK accepts.
+func newID(label string, values ...string) string {
+	var b [][]byte
+	for _, v := range values {
+		b = append(b, []byte(v))
+	}
+	return hex.EncodeToString(digest(label, b...))[:28]
+}
+
+func pathBytes(path []uint32) []byte {
+	var b []byte
+	for _, p := range path {
+		b = binary.BigEndian.AppendUint32(b, p)
+	}
+	return b
+}
+
+// Returns a non-zero scalar modulo order, derived from a label and a list of values
+func scalar(order *big.Int, label string, values ...[]byte) *big.Int {
+	x := new(big.Int).SetBytes(digest(label, values...))
+	x.Mod(x, new(big.Int).Sub(order, big.NewInt(1)))
+	return x.Add(x, big.NewInt(1))
+}
+
+// Returns the order of the group of the key
+func (k *key) order() *big.Int {
+	if k.scheme == schemeSchnorr {
+		return ed25519Order
+	}
+	return ecdsaCurves[k.curve].Params().N
+}
+
+// Returns the private key, derived along path. The derivation is not BIP-32; it only needs to be the same on all nodes.
+func (k *key) privateKey(path []uint32) *big.Int {
+	return scalar(k.order(), "privateKey", k.seed, pathBytes(path))
+}
+
+// Returns the public key derived along path: a compressed point for ECDSA, and the Ed25519 encoding for Schnorr
+func (k *key) publicKey(path []uint32) []byte {
+	x := k.privateKey(path)
+	if k.scheme == schemeSchnorr {
+		return new(edwards25519.Point).ScalarBaseMult(ed25519Scalar(x)).Bytes()
+	}
+	curve := ecdsaCurves[k.curve]
+	px, py := curve.ScalarBaseMult(x.Bytes())
+	return elliptic.MarshalCompressed(curve, px, py)
+}
+
+// Checks the length of an ECDSA message hash, which must be as long as the order of the curve, or 64 bytes for P-521
+func (k *key) checkMessage(message []byte) error {
+	if k.scheme != schemeECDSA {
+		return nil
+	}
+	length := (k.order().BitLen() + 7) / 8
+	if k.curve == "P-521" {
+		length = 64
+	}
+	if len(message) != length {
+		return errorf(http.StatusBadRequest, "message hash for %s must be %d bytes, got %d", k.curve, length, len(message))
+	}
+	return nil
+}
+
+type signResponse struct {
+	PresignatureID string `json:"presignatureId,omitempty"`
+	SchnorrVariant string `json:"schnorr_variant,omitempty"`
+	Curve          string `json:"curve"`
+	PlayerIndex    int    `json:"playerIndex"`
+	Threshold      int    `json:"threshold"`
+	Sharing        string `json:"sharing"`
+	SShare         []byte `json:"sShare"`
+	R              []byte `json:"r"`
+	PublicKey      []byte `json:"publicKey"`
+}
+
+// Computes the signature of message with the key derived along path, and returns the additive share of player. The
+// nonce and the shares are derived from nonceSeed, which is the session ID, or the presignature ID when signing with
+// a presignature.
+func (k *key) sign(path []uint32, message []byte, nonceSeed string, players []int, player int) *signResponse {
+	publicKey := k.publicKey(path)
+	order := k.order()
+	x := k.privateKey(path)
+	nonce := scalar(order, "nonce", k.seed, pathBytes(path), []byte(nonceSeed), message)
+
+	var r []byte
+	s := new(big.Int)
+	if k.scheme == schemeSchnorr {
+		// s = nonce + H(R || A || M) * x
+		r = new(edwards25519.Point).ScalarBaseMult(ed25519Scalar(nonce)).Bytes()
+		challenge := sha512.Sum512(slices.Concat(r, publicKey, message))
+		slices.Reverse(challenge[:])
+		s.SetBytes(challenge[:])
+		s.Mul(s, x).Add(s, nonce).Mod(s, order)
+	} else {
+		// s = nonce^-1 * (e + r * x), where r is the x-coordinate of R
+		curve := ecdsaCurves[k.curve]
+		rx, ry := curve.ScalarBaseMult(nonce.Bytes())
+		r = elliptic.MarshalCompressed(curve, rx, ry)
+		s.Mul(new(big.Int).Mod(rx, order), x).Add(s, hashToInt(message, order))
+		s.Mul(s, new(big.Int).ModInverse(nonce, order)).Mod(s, order)
+	}
+
+	response := &signResponse{
+		PlayerIndex: player,
+		Threshold:   len(players) - 1,
+		Sharing:     "additive",
+		SShare:      additiveShare(s, order, nonceSeed, players, player).FillBytes(make([]byte, (order.BitLen()+7)/8)),
+		R:           r,
+		PublicKey:   publicKey,
+	}
+	if k.scheme == schemeSchnorr {
+		response.SchnorrVariant, response.Curve = k.curve, ed25519Curve
+	} else {
+		response.Curve = k.curve
+	}
+	return response
+}
+
+// Returns the share of player of an additive sharing of s between the players. The shares of all players but the
+// first are derived from seed, and the first player gets the remainder.
+func additiveShare(s, order *big.Int, seed string, players []int, player int) *big.Int {
+	share := func(p int) *big.Int {
+		return scalar(order, "share", []byte(seed), binary.BigEndian.AppendUint64(nil, uint64(p)))
+	}
+	if player != players[0] {
+		return share(player)
+	}
+	first := new(big.Int).Set(s)
+	for _, p := range players[1:] {
+		first.Sub(first, share(p))
+	}
+	return first.Mod(first, order)
+}
+
+// Converts a message hash to an integer as ECDSA does, by taking the leftmost bits of the hash, as many as the bit
+// length of the order
+func hashToInt(hash []byte, order *big.Int) *big.Int {
+	orderBits := order.BitLen()
+	if orderBytes := (orderBits + 7) / 8; len(hash) > orderBytes {
+		hash = hash[:orderBytes]
+	}
+	e := new(big.Int).SetBytes(hash)
+	if excess := len(hash)*8 - orderBits; excess > 0 {
+		e.Rsh(e, uint(excess))
+	}
+	return e
+}
+
+// Converts a scalar modulo the order of Ed25519 to its little-endian encoding
+func ed25519Scalar(x *big.Int) *edwards25519.Scalar {
+	b := x.FillBytes(make([]byte, 32))
+	slices.Reverse(b)
+	s, err := new(edwards25519.Scalar).SetCanonicalBytes(b)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
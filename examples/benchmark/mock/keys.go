@@ -0,0 +1,287 @@
+package mock
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// A key share held by a mock node. The key is derived from the seed, which is the same on all nodes.
+type key struct {
+	scheme string
+	// The ECDSA curve, or the Schnorr variant
+	curve     string
+	threshold int
+	seed      []byte
+}
+
+// A presignature, to be used once. Signing with it takes the same players as the session that generated it.
+type presignature struct {
+	keyID   string
+	players []int
+}
+
+type keyRequest struct {
+	Threshold      int    `json:"threshold"`
+	Curve          string `json:"curve"`
+	SchnorrVariant string `json:"schnorr_variant"`
+	KeyID          string `json:"keyID"`
+}
+
+// Returns the ECDSA curve or the Schnorr variant of the request, whichever applies to the scheme
+func (k keyRequest) curveOf(scheme string) string {
+	if scheme == schemeSchnorr {
+		return k.SchnorrVariant
+	}
+	return k.Curve
+}
+
+type signRequest struct {
+	ChainPath      []uint32 `json:"chainPath"`
+	MessageHash    []byte   `json:"messageHash"`
+	Message        []byte   `json:"message"`
+	PresignatureID string   `json:"presignatureId"`
+}
+
+// Returns the message hash for ECDSA, or the message for Schnorr
+func (s signRequest) messageOf(scheme string) []byte {
+	if scheme == schemeSchnorr {
+		return s.Message
+	}
+	return s.MessageHash
+}
+
+type pathRequest struct {
+	ChainPath []uint32 `json:"chainPath"`
+}
+
+func (n *Node) generateKey(r *request) (any, error) {
+	if err := n.checkSession(r.sessionID, r.players); err != nil {
+		return nil, err
+	}
+	var in keyRequest
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	curve := in.curveOf(r.scheme)
+	if err := checkCurve(r.scheme, curve); err != nil {
+		return nil, err
+	}
+	if in.Threshold < 1 || in.Threshold >= len(r.players) {
+		return nil, errorf(http.StatusBadRequest, "invalid threshold %d for %d players", in.Threshold, len(r.players))
+	}
+	keyID := in.KeyID
+	if keyID == "" {
+		keyID = newID("keyID", r.sessionID)
+	}
+	k := &key{scheme: r.scheme, curve: curve, threshold: in.Threshold, seed: digest("seed", []byte(r.sessionID))}
+	if err := n.addKey(keyID, k); err != nil {
+		return nil, err
+	}
+	return map[string]string{"keyID": keyID}, nil
+}
+
+func (n *Node) generatePresignatures(r *request) (any, error) {
+	if err := n.checkSession(r.sessionID, r.players); err != nil {
+		return nil, err
+	}
+	keyID := r.PathValue("keyID")
+	k, err := n.key(keyID, r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.ParseUint(r.PathValue("count"), 10, 32)
+	if err != nil || count == 0 {
+		return nil, errorf(http.StatusBadRequest, "invalid presignature count: %s", r.PathValue("count"))
+	}
+	if len(r.players) <= k.threshold {
+		return nil, errorf(http.StatusBadRequest, "not enough players for threshold %d: %d", k.threshold, len(r.players))
+	}
+	ids := make([]string, count)
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for i := range ids {
+		ids[i] = newID("presignatureID", r.sessionID, strconv.Itoa(i))
+		n.presig[ids[i]] = &presignature{keyID: keyID, players: r.players}
+	}
+	return map[string][]string{"ids": ids}, nil
+}
+
+func (n *Node) sign(r *request) (any, error) {
+	if err := n.checkSession(r.sessionID, r.players); err != nil {
+		return nil, err
+	}
+	k, err := n.key(r.PathValue("keyID"), r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	var in signRequest
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if len(r.players) <= k.threshold {
+		return nil, errorf(http.StatusBadRequest, "not enough players for threshold %d: %d", k.threshold, len(r.players))
+	}
+	if err := k.checkMessage(in.messageOf(r.scheme)); err != nil {
+		return nil, err
+	}
+	return k.sign(in.ChainPath, in.messageOf(r.scheme), r.sessionID, r.players, n.player), nil
+}
+
+// Signs with a presignature, which is used up. The nonce of the signature is derived from the presignature, and the
+// shares are split between the players of the session that generated it.
+func (n *Node) signWithPresignature(r *request) (any, error) {
+	keyID := r.PathValue("keyID")
+	k, err := n.key(keyID, r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	var in signRequest
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	n.lock.Lock()
+	p := n.presig[in.PresignatureID]
+	if p != nil && p.keyID == keyID {
+		delete(n.presig, in.PresignatureID)
+	}
+	n.lock.Unlock()
+	if p == nil || p.keyID != keyID {
+		return nil, errorf(http.StatusBadRequest, "no such presignature for key %s: %s", keyID, in.PresignatureID)
+	}
+	if err := k.checkMessage(in.messageOf(r.scheme)); err != nil {
+		return nil, err
+	}
+	result := k.sign(in.ChainPath, in.messageOf(r.scheme), in.PresignatureID, p.players, n.player)
+	result.PresignatureID = in.PresignatureID
+	return result, nil
+}
+
+func (n *Node) publicKey(r *request) (any, error) {
+	k, err := n.key(r.PathValue("keyID"), r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	var in pathRequest
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	publicKey := k.publicKey(in.ChainPath)
+	if r.scheme == schemeSchnorr {
+		return map[string]any{"schnorr_variant": k.curve, "curve": ed25519Curve, "publicKey": publicKey}, nil
+	}
+	return map[string]any{"curve": k.curve, "publicKey": publicKey}, nil
+}
+
+func (n *Node) chainCode(r *request) (any, error) {
+	k, err := n.key(r.PathValue("keyID"), r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	var in pathRequest
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	chainCode := digest("chainCode", k.seed, pathBytes(in.ChainPath))[:32]
+	if r.scheme == schemeSchnorr {
+		return map[string]any{"schnorr_variant": k.curve, "chainCode": chainCode}, nil
+	}
+	return map[string]any{"chainCode": chainCode}, nil
+}
+
+// Resharing leaves the key as it is, since the shares of a mock node are computed when signing
+func (n *Node) reshare(r *request) (any, error) {
+	if err := n.checkSession(r.sessionID, r.players); err != nil {
+		return nil, err
+	}
+	k, err := n.key(r.PathValue("keyID"), r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.players) <= k.threshold {
+		return nil, errorf(http.StatusBadRequest, "not enough players for threshold %d: %d", k.threshold, len(r.players))
+	}
+	return nil, nil
+}
+
+// Copies a key to a new key ID, with a new threshold. The copy has the same private key as the original.
+func (n *Node) copyKey(r *request) (any, error) {
+	if err := n.checkSession(r.sessionID, r.players); err != nil {
+		return nil, err
+	}
+	k, err := n.key(r.PathValue("keyID"), r.scheme)
+	if err != nil {
+		return nil, err
+	}
+	var in keyRequest
+	if err := r.decode(&in); err != nil {
+		return nil, err
+	}
+	if curve := in.curveOf(r.scheme); curve != "" && curve != k.curve {
+		return nil, errorf(http.StatusBadRequest, "cannot copy a key on %s to %s", k.curve, curve)
+	}
+	if in.Threshold < 1 || in.Threshold >= len(r.players) {
+		return nil, errorf(http.StatusBadRequest, "invalid threshold %d for %d players", in.Threshold, len(r.players))
+	}
+	keyID := in.KeyID
+	if keyID == "" {
+		keyID = newID("keyID", r.sessionID)
+	}
+	if err := n.addKey(keyID, &key{scheme: k.scheme, curve: k.curve, threshold: in.Threshold, seed: k.seed}); err != nil {
+		return nil, err
+	}
+	return map[string]string{"keyID": keyID}, nil
+}
+
+// Deletes a key share, along with the presignatures of the key
+func (n *Node) deleteKey(r *request) (any, error) {
+	keyID := r.PathValue("keyID")
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.keys[keyID] == nil {
+		return nil, errorf(http.StatusNotFound, "no such key: %s", keyID)
+	}
+	delete(n.keys, keyID)
+	for id, p := range n.presig {
+		if p.keyID == keyID {
+			delete(n.presig, id)
+		}
+	}
+	return nil, nil
+}
+
+// Deletes the presignatures of a key
+func (n *Node) deletePresignatures(r *request) (any, error) {
+	keyID := r.PathValue("keyID")
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.keys[keyID] == nil {
+		return nil, errorf(http.StatusNotFound, "no such key: %s", keyID)
+	}
+	for id, p := range n.presig {
+		if p.keyID == keyID {
+			delete(n.presig, id)
+		}
+	}
+	return nil, nil
+}
+
+func (n *Node) addKey(keyID string, k *key) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.keys[keyID] != nil {
+		return errorf(http.StatusBadRequest, "key already exists: %s", keyID)
+	}
+	n.keys[keyID] = k
+	return nil
+}
+
+// Returns the key with the given ID, which must be a key of the scheme
+func (n *Node) key(keyID, scheme string) (*key, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	k := n.keys[keyID]
+	if k == nil || k.scheme != scheme {
+		return nil, errorf(http.StatusNotFound, "no such %s key: %s", scheme, keyID)
+	}
+	return k, nil
+}
@@ -0,0 +1,221 @@
+// Package mock provides a stand-in for a cluster of MPC nodes, for running the benchmark offline. A mock node serves
+// enough of the node API for tsm.Client to generate keys and presignatures, sign, and read public keys and chain
+// codes, with ECDSA keys on all curves and Schnorr keys of variant Ed25519. It also reshares, copies and deletes keys,
+// and deletes presignatures. Other operations fail with 404: the mock has no backup and restore of key shares, no
+// recovery data, no export and import of key shares and no BIP32 seeds and derivation, so the operations backupDrill,
+// recoveryDrill, exportImport and bip32 of the benchmark cannot be run against it.
+//
+// The nodes do not talk to each other. Instead, every key is derived from a seed that all nodes compute from the
+// session, so each node can compute the complete signature of a session on its own and return its additive share of
+// it. The shares combine to a signature that verifies against the public key, but the keys are not secret: a mock
+// node is for testing the benchmark, not the MPC protocols.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"golang.org/x/exp/rand"
+)
+
+// Config sets the behaviour of a mock node
+type Config struct {
+	// Added to every session request, plus a uniformly random delay of up to Jitter
+	Latency time.Duration
+	Jitter  time.Duration
+	// Probability that a session request fails with an HTTP 500 instead of being served
+	FailureRate float64
+}
+
+func (c Config) Validate() error {
+	if c.Latency < 0 || c.Jitter < 0 {
+		return fmt.Errorf("invalid latency or jitter")
+	}
+	if c.FailureRate < 0 || c.FailureRate > 1 {
+		return fmt.Errorf("invalid failure rate: %v", c.FailureRate)
+	}
+	return nil
+}
+
+// Protocol numbers reported to the SDK: DKLS19 for ECDSA and SEPD19S for Schnorr, which the SDK knows how to combine
+// partial signatures of
+const (
+	protocolDKLS19  = 10
+	protocolSEPD19S = 3
+)
+
+// Node is a mock MPC node. It accepts any API key.
+type Node struct {
+	player int
+	config Config
+	mux    *http.ServeMux
+
+	lock   sync.Mutex
+	keys   map[string]*key
+	presig map[string]*presignature
+}
+
+// NewNode returns a mock node that plays the player with the given index in the sessions it takes part in
+func NewNode(player int, config Config) *Node {
+	n := &Node{player: player, config: config, mux: http.NewServeMux(), keys: map[string]*key{}, presig: map[string]*presignature{}}
+	n.mux.HandleFunc("GET /info/protocols", n.protocols)
+	n.mux.HandleFunc("GET /version", n.version)
+	for _, scheme := range []string{schemeECDSA, schemeSchnorr} {
+		prefix := "POST /" + strings.ToLower(scheme) + "/keys"
+		n.mux.Handle(prefix, n.session(scheme, n.generateKey))
+		n.mux.Handle(prefix+"/{keyID}/{count}/presiggen", n.session(scheme, n.generatePresignatures))
+		n.mux.Handle(prefix+"/{keyID}/sign", n.session(scheme, n.sign))
+		n.mux.Handle(prefix+"/{keyID}/signwithpresig", n.session(scheme, n.signWithPresignature))
+		n.mux.Handle(prefix+"/{keyID}/publickey", n.session(scheme, n.publicKey))
+		n.mux.Handle(prefix+"/{keyID}/chaincode", n.session(scheme, n.chainCode))
+		n.mux.Handle(prefix+"/{keyID}/reshare", n.session(scheme, n.reshare))
+		n.mux.Handle(prefix+"/{keyID}/keycopy", n.session(scheme, n.copyKey))
+	}
+	n.mux.Handle("DELETE /key/{keyID}", n.session("", n.deleteKey))
+	n.mux.Handle("DELETE /key/{keyID}/presigs", n.session("", n.deletePresignatures))
+	return n
+}
+
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Like a real node, the version is served without authentication
+	if r.URL.Path != "/version" && !strings.HasPrefix(r.Header.Get("Authorization"), "APIKEY ") {
+		http.Error(w, "missing API key", http.StatusUnauthorized)
+		return
+	}
+	n.mux.ServeHTTP(w, r)
+}
+
+func (n *Node) protocols(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]int{"ecdsa": protocolDKLS19, "schnorr": protocolSEPD19S})
+}
+
+// Reports the version of the SDK as the version of the node, so the SDK does not warn about a version mismatch
+func (n *Node) version(w http.ResponseWriter, _ *http.Request) {
+	v := (&tsm.Client{}).SDKVersion()
+	writeJSON(w, map[string]string{
+		"version":             v.Version,
+		"clientapi":           v.ClientAPI,
+		"clientcommunication": v.ClientCommunication,
+		"nodecommunication":   v.NodeCommunication,
+		"nodeconfiguration":   v.NodeConfiguration,
+	})
+}
+
+// A request to a mock node, along with the session it belongs to
+type request struct {
+	*http.Request
+	scheme    string
+	sessionID string
+	// The player indices of the session, in increasing order
+	players []int
+}
+
+// An error with the HTTP status that the node returns for it
+type statusError struct {
+	status  int
+	message string
+}
+
+func (e *statusError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, a ...any) error {
+	return &statusError{status: status, message: fmt.Sprintf(format, a...)}
+}
+
+// Returns a handler that reads the session of a request, applies the latency and failures of the node, and writes
+// the result of f as JSON
+func (n *Node) session(scheme string, f func(r *request) (any, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &request{Request: r, scheme: scheme, sessionID: r.Header.Get("MPC-SessionID")}
+		for _, v := range r.Header.Values("MPC-Players") {
+			for _, s := range strings.Split(v, ",") {
+				index, _, _ := strings.Cut(strings.TrimSpace(s), " ")
+				playerIndex, err := strconv.Atoi(index)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("invalid player index in header: %s", err), http.StatusBadRequest)
+					return
+				}
+				req.players = append(req.players, playerIndex)
+			}
+		}
+		sort.Ints(req.players)
+
+		if err := n.delay(r.Context()); err != nil {
+			return
+		}
+		if n.config.FailureRate > 0 && rand.Float64() < n.config.FailureRate {
+			http.Error(w, "mock node: injected failure", http.StatusInternalServerError)
+			return
+		}
+
+		result, err := f(req)
+		var statusErr *statusError
+		switch {
+		case errors.As(err, &statusErr):
+			http.Error(w, statusErr.message, statusErr.status)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		case result == nil:
+			w.WriteHeader(http.StatusOK)
+		default:
+			writeJSON(w, result)
+		}
+	})
+}
+
+// Sleeps for the latency of the node, or until ctx is cancelled
+func (n *Node) delay(ctx context.Context) error {
+	d := n.config.Latency
+	if n.config.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(n.config.Jitter)))
+	}
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Checks that the node takes part in a session with at least two players
+func (n *Node) checkSession(sessionID string, players []int) error {
+	if sessionID == "" {
+		return errorf(http.StatusBadRequest, "missing session ID")
+	}
+	if len(players) < 2 {
+		return errorf(http.StatusBadRequest, "a session needs at least two players, got %d", len(players))
+	}
+	if !slices.Contains(players, n.player) {
+		return errorf(http.StatusBadRequest, "player %d is not in the session", n.player)
+	}
+	return nil
+}
+
+func (r *request) decode(v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request: %s", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
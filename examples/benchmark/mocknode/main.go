@@ -0,0 +1,90 @@
+// Command mocknode runs a cluster of mock MPC nodes on local ports, for running the benchmark without a TSM. The mock
+// nodes serve key generation, presignatures, signing, public keys and chain codes for ECDSA keys and Ed25519 Schnorr
+// keys, with configurable latency and failure rate. See package benchmark/mock.
+package main
+
+import (
+	"benchmark/mock"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func main() {
+	if err := run(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var nodes int
+	var listen string
+	var config mock.Config
+	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flagSet.IntVar(&nodes, "nodes", 3, "Number of mock nodes")
+	flagSet.StringVar(&listen, "listen", "127.0.0.1:8600", "Address of the first node; node i listens on the port after node i-1")
+	flagSet.DurationVar(&config.Latency, "latency", 0, "Latency added to every session request")
+	flagSet.DurationVar(&config.Jitter, "jitter", 0, "Maximum random latency added to every session request on top of -latency")
+	flagSet.Float64Var(&config.FailureRate, "failureRate", 0, "Probability that a session request fails with an HTTP 500")
+	if err := flagSet.Parse(os.Args[1:]); err != nil {
+		return err
+	}
+	if nodes < 2 {
+		_, _ = fmt.Fprintf(os.Stderr, "not enough nodes: %d\n", nodes)
+		flagSet.Usage()
+		os.Exit(1)
+	}
+	if err := config.Validate(); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		flagSet.Usage()
+		os.Exit(1)
+	}
+
+	host, portString, err := net.SplitHostPort(listen)
+	if err != nil {
+		return fmt.Errorf("invalid listen address: %w", err)
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return fmt.Errorf("invalid listen port: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var servers []*http.Server
+	for i := 0; i < nodes; i++ {
+		addr := net.JoinHostPort(host, strconv.Itoa(port+i))
+		servers = append(servers, &http.Server{Addr: addr, Handler: mock.NewNode(i, config)})
+		fmt.Printf("Node %d: http://apikey%d@%s\n", i, i, addr)
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, server := range servers {
+		server := server
+		eg.Go(func() error {
+			if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		})
+	}
+	eg.Go(func() error {
+		<-ctx.Done()
+		for _, server := range servers {
+			_ = server.Close()
+		}
+		return nil
+	})
+	return eg.Wait()
+}
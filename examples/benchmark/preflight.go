@@ -0,0 +1,54 @@
+package main
+
+import (
+	"benchmark/bench"
+	"benchmark/flags"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func preflightCommand(name string, args []string) {
+	options := bench.DefaultOptions()
+	options.Log = os.Stdout
+
+	var algorithmClients clientsFlag
+	var nodeURLs flags.URLArray
+
+	flagSet := flag.NewFlagSet(name, flag.ExitOnError)
+	config := addConfigFlags(flagSet)
+	config.ignoreUnknown = true
+	flagSet.Var(&nodeURLs, "node", "Specify an MPC node. Example: http://apikey@localhost:8080")
+	flagSet.Var(&algorithmClients, "clients", "Algorithms to check, as for run, such as ECDSA/P-256=1,Schnorr/BIP-340=1; the client counts are ignored. Default is ECDSA/secp256k1")
+	flagSet.IntVar(&options.Threshold, "threshold", 0, "Security threshold of the test keys. Default is number of MPC nodes - 1")
+	flagSet.IntVar(&options.Signers, "signers", 0, "Number of nodes to participate in signing. Default is threshold + 1")
+	flagSet.DurationVar(&options.SessionTimeout, "sessionTimeout", 10*time.Second, "Fail a check that has not completed within this duration. Zero waits for the MPC nodes to time out")
+	setUsage(flagSet, "[-config <file>] -node <url> -node <url> ... [flags]",
+		"Checks that a cluster of MPC nodes is ready for a benchmark: every node must report its TSM version, the nodes\n"+
+			"must run the same version, compatible with the SDK, and for each algorithm a test key must be generated, signed\n"+
+			"with by a random set of signers and verified locally, and deleted again. Exits with an error if any check fails.")
+	_ = flagSet.Parse(args)
+	if flagSet.NArg() > 0 {
+		usageError(flagSet, "unexpected arguments:", strings.Join(flagSet.Args(), " "))
+	}
+
+	options.Nodes = applyConfig(flagSet, config, nodeURLs)
+	options.Clients = algorithmClients
+	if len(options.Clients) == 0 {
+		options.Clients = []bench.AlgorithmClients{{Algorithm: bench.Algorithm{Scheme: "ECDSA", Curve: "secp256k1"}, Clients: 1}}
+	}
+
+	b, err := bench.New(options)
+	if err != nil {
+		usageError(flagSet, err)
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	if err := b.Preflight(ctx); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"benchmark/bench"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func reportCommand(name string, args []string) {
+	flagSet := flag.NewFlagSet(name, flag.ExitOnError)
+	setUsage(flagSet, "<result.json> ...",
+		"Prints the parameters and the report of results written by run with -output, as run prints them when it ends.\n"+
+			"Only results in format json can be read.")
+	_ = flagSet.Parse(args)
+	if flagSet.NArg() == 0 {
+		usageError(flagSet, "no result files given")
+	}
+
+	for i, path := range flagSet.Args() {
+		r, err := bench.ReadResult(path)
+		if err != nil {
+			_, _ = fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		printParameters(path, r)
+		bench.WriteReport(os.Stdout, r)
+	}
+}
+
+// Prints the main parameters of a saved result
+func printParameters(path string, r *bench.Result) {
+	p := r.Parameters
+	fmt.Println("Result:          ", path)
+	fmt.Println("Started:         ", r.StartTime.Format(time.RFC3339))
+	if r.Scenario != nil {
+		fmt.Println("Scenario:        ", r.Scenario.File)
+	} else {
+		fmt.Println("Operation:       ", p.Operation)
+	}
+	fmt.Println("MPC nodes:       ", len(p.Nodes))
+	var algorithms []string
+	for algorithm := range p.Clients {
+		algorithms = append(algorithms, algorithm)
+	}
+	sort.Strings(algorithms)
+	for _, algorithm := range algorithms {
+		fmt.Printf("%-17s %d\n", algorithm+" clients:", p.Clients[algorithm])
+	}
+	fmt.Println("Threshold:       ", p.Threshold)
+	fmt.Println("Signers:         ", p.Signers)
+	fmt.Println("Test duration:   ", r.TestDuration())
+	if r.Ramp != nil {
+		fmt.Println("Ramp:            ", r.Ramp.Mode, "of", r.Ramp.Dimension, "from", r.Ramp.Start, "to", r.Ramp.Max, "by", r.Ramp.Increment)
+	}
+	if p.Rate > 0 {
+		fmt.Println("Arrival rate:    ", p.Rate, "sessions/sec per algorithm")
+	}
+	if len(r.FaultProfiles) > 0 {
+		var profiles []string
+		for _, change := range r.FaultProfiles {
+			profiles = append(profiles, change.Profile)
+		}
+		fmt.Println("Fault profiles:  ", strings.Join(profiles, ", "))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"benchmark/bench"
+	"benchmark/flags"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+)
+
+func runCommand(name string, args []string) {
+	b, output, outputFormat := newBenchmark(name, args)
+
+	// The first signal stops the benchmark from starting new sessions and lets the sessions in flight complete, so that
+	// the results can be reported. A second signal also cancels the sessions in flight.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Println("Stopping; waiting for the sessions in flight. Interrupt again to cancel them")
+		b.Stop()
+		<-signals
+		fmt.Println("Cancelling the sessions in flight")
+		cancel()
+		// A third signal terminates the process right away
+		signal.Stop(signals)
+	}()
+
+	result, err := b.Run(ctx)
+	if result != nil && output != "" {
+		if err := bench.WriteResult(*result, output, outputFormat); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error writing result to %s: %s\n", output, err)
+			os.Exit(1)
+		}
+		fmt.Println("Result written to", output)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Creates the benchmark from the command line, and returns it along with the file and format to write the result to
+func newBenchmark(name string, args []string) (b *bench.Benchmark, output, outputFormat string) {
+	options := bench.DefaultOptions()
+	options.Log = os.Stdout
+
+	var ecdsaClients, ed25519Clients int
+	var ecdsaCurve, ed25519Curve string
+	var algorithmClients clientsFlag
+	var nodeURLs flags.URLArray
+
+	flagSet := flag.NewFlagSet(name, flag.ExitOnError)
+	config := addConfigFlags(flagSet)
+	flagSet.StringVar(&options.Operation, "operation", options.Operation, "Operation to perform; one of: sign, presigGen, onlineSign, getpub, keygen, reshare, exportImport, bip32, backupDrill, recoveryDrill. The drills run once per client, one after the other, each with a new key")
+	flagSet.Var(&nodeURLs, "node", "Specify an MPC node. Example: http://apikey@localhost:8080")
+	flagSet.Var(&algorithmClients, "clients", "Number of concurrent clients per algorithm, as a comma-separated list of algorithm=clients. Example: ECDSA/P-256=10,Schnorr/BIP-340=5. Each algorithm gets its own key and its own results")
+	flagSet.IntVar(&ecdsaClients, "ecdsaClients", 0, "Number of concurrent clients doing ECDSA signature requests. Short for -clients ECDSA/<ecdsaCurve>=<ecdsaClients>")
+	flagSet.IntVar(&ed25519Clients, "ed25519Clients", 0, "Number of concurrent clients doing Ed25519 signature requests. Short for -clients Schnorr/<ed25519Curve>=<ed25519Clients>")
+	flagSet.StringVar(&ecdsaCurve, "ecdsaCurve", "secp256k1", "Curve of the keys of the -ecdsaClients; one of: "+strings.Join(bench.ECDSACurves, ", "))
+	flagSet.StringVar(&ed25519Curve, "ed25519Curve", tsm.SchnorrEd25519, "Schnorr variant of the keys of the -ed25519Clients; one of: "+strings.Join(bench.SchnorrVariants, ", "))
+	flagSet.IntVar(&options.Threshold, "threshold", 0, "Security threshold. Default is number of MPC nodes - 1")
+	flagSet.IntVar(&options.Signers, "signers", 0, "Number of nodes to participate in signing. Default is threshold + 1. A random set of this size is chosen for each signature.")
+	flagSet.DurationVar(&options.Duration, "duration", options.Duration, "For how long should the test run. A scenario runs for the duration of its phases instead")
+	flagSet.BoolVar(&options.ShowProgress, "showProgress", false, "Print a line for each generated signature")
+	flagSet.DurationVar(&options.Delay, "delay", 0, "Duration that each client will sleep between each signature")
+	flagSet.DurationVar(&options.SessionTimeout, "sessionTimeout", 0, "Abort a session that has not completed within this duration. Zero waits for the MPC nodes to time out. The time spent in aborted sessions is reported separately")
+	flagSet.Float64Var(&options.VerifyRate, "verifyRate", 0, "Fraction of the signatures of operations sign and onlineSign that are combined and verified locally against the derived public key; 1 verifies all signatures. Invalid signatures are counted on their own. Not supported with -scenario")
+	flagSet.StringVar(&options.KeyFile, "keyFile", "", "Append the IDs of the keys that the benchmark leaves on the nodes to this file, for the cleanup command")
+
+	flagSet.IntVar(&options.ReshareThreshold, "reshareThreshold", 0, "If set, operation reshare reshares keys with this threshold instead of the -threshold. As resharing keeps the threshold of a key, each key is generated with -threshold and copied to a new key with this threshold before the test; the original key is deleted")
+	flagSet.IntVar(&options.SignDuringReshare, "signDuringReshare", 0, "Number of clients per algorithm that sign with the keys while operation reshare is running")
+
+	flagSet.StringVar(&options.BIP32Path, "bip32Path", options.BIP32Path, "Derivation path of the child keys derived by operation bip32. Elements ending with ' are hardened. Operation bip32 only supports ECDSA/secp256k1 clients")
+
+	flagSet.IntVar(&options.PresigCount, "presigCount", options.PresigCount, "Total number of presignatures each client will generate, if possible within test duration")
+	flagSet.Uint64Var(&options.PresigBatchSize, "presigBatchSize", options.PresigBatchSize, "Presiganture batch size")
+	flagSet.StringVar(&options.PresigDir, "presigDir", options.PresigDir, "Directory for storing presig IDs")
+
+	flagSet.StringVar(&options.ScenarioFile, "scenario", "", "Run the mixed workload described in this JSON scenario file instead of a single operation")
+
+	flagSet.Float64Var(&options.Rate, "rate", 0, "Run in open-loop mode, starting this many sessions per second per algorithm regardless of completions. Only for sign, getpub and keygen. The client counts then only select the algorithms")
+	flagSet.StringVar(&options.Arrivals, "arrivals", options.Arrivals, "Session arrivals in open-loop mode; one of: fixed, poisson")
+	flagSet.IntVar(&options.MaxInFlight, "maxInFlight", options.MaxInFlight, "Maximum number of sessions per algorithm in flight in open-loop mode. Sessions due while at the limit are dropped")
+
+	flagSet.StringVar(&options.Ramp, "ramp", "", "Ramp the load up in steps, holding each step for the test duration; one of: steps, search. Ramps clients per algorithm, or the arrival rate if -rate is set. Only for sign, getpub and keygen")
+	flagSet.Float64Var(&options.RampStart, "rampStart", options.RampStart, "Load of the first ramp step")
+	flagSet.Float64Var(&options.RampIncrement, "rampIncrement", options.RampIncrement, "Load increment between ramp steps; in search mode, the resolution of the search")
+	flagSet.Float64Var(&options.RampMax, "rampMax", options.RampMax, "Load of the last ramp step")
+	flagSet.DurationVar(&options.SLOP99, "sloP99", 0, "Latency SLO: a ramp step is within SLO only if the p99 latency is at most this. Zero disables the latency SLO")
+	flagSet.Float64Var(&options.SLOErrorRate, "sloErrorRate", options.SLOErrorRate, "Error budget: a ramp step is within SLO only if at most this fraction of the sessions failed or were dropped")
+
+	flagSet.StringVar(&output, "output", "", "Write the benchmark parameters and results to this file")
+	flagSet.StringVar(&outputFormat, "outputFormat", "json", "Format of the -output file; one of: json, csv")
+	flagSet.StringVar(&options.FaultProxy, "faultProxy", "", "Control endpoint of the faultproxy command in front of the nodes, e.g. http://127.0.0.1:8499. The results are labelled with the fault profiles active during the run")
+
+	setUsage(flagSet, "[-config <file>] -node <url> -node <url> ... [flags]",
+		"Runs an operation, a ramp or a scenario against a cluster of MPC nodes, and reports the throughput, latency and\n"+
+			"failures. Give at least two nodes, and either clients or a scenario, on the command line or in a configuration file.",
+		flagSection{"Configuration", []string{"config", "printConfig"}},
+		flagSection{"General", []string{"operation", "node", "clients", "ecdsaClients", "ed25519Clients", "ecdsaCurve", "ed25519Curve", "threshold", "signers", "duration", "delay", "sessionTimeout", "verifyRate", "showProgress", "keyFile"}},
+		flagSection{"Operation reshare", []string{"reshareThreshold", "signDuringReshare"}},
+		flagSection{"Operation bip32", []string{"bip32Path"}},
+		flagSection{"Operations presigGen and onlineSign", []string{"presigCount", "presigBatchSize", "presigDir"}},
+		flagSection{"Scenario", []string{"scenario"}},
+		flagSection{"Open-loop mode", []string{"rate", "arrivals", "maxInFlight"}},
+		flagSection{"Ramp mode", []string{"ramp", "rampStart", "rampIncrement", "rampMax", "sloP99", "sloErrorRate"}},
+		flagSection{"Output", []string{"output", "outputFormat", "faultProxy"}},
+	)
+	_ = flagSet.Parse(args)
+	if flagSet.NArg() > 0 {
+		usageError(flagSet, "unexpected arguments:", strings.Join(flagSet.Args(), " "))
+	}
+
+	options.Nodes = applyConfig(flagSet, config, nodeURLs)
+
+	if ecdsaClients > 0 {
+		options.Clients = append(options.Clients, bench.AlgorithmClients{Algorithm: bench.Algorithm{Scheme: "ECDSA", Curve: ecdsaCurve}, Clients: ecdsaClients})
+	}
+	if ed25519Clients > 0 {
+		options.Clients = append(options.Clients, bench.AlgorithmClients{Algorithm: bench.Algorithm{Scheme: "Schnorr", Curve: ed25519Curve}, Clients: ed25519Clients})
+	}
+	options.Clients = append(options.Clients, algorithmClients...)
+
+	if outputFormat != "json" && outputFormat != "csv" {
+		usageError(flagSet, "invalid output format:", outputFormat)
+	}
+
+	b, err := bench.New(options)
+	if err != nil {
+		usageError(flagSet, err)
+	}
+
+	return b, output, outputFormat
+}
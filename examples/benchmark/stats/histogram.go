@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Values below subBucketCount are recorded exactly. Larger values are recorded in log-linear buckets, where each power
+// of two is split into subBucketCount/2 sub-buckets. This bounds the relative error of any recorded value to 1/64.
+const (
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits
+	subBucketHalf  = subBucketCount / 2
+	bucketCount    = subBucketCount + (64-subBucketBits)*subBucketHalf
+)
+
+// Histogram is an HDR-style latency histogram with a fixed memory footprint. It is not safe for concurrent use; keep
+// one histogram per goroutine and merge them when done.
+type Histogram struct {
+	counts [bucketCount]uint64
+	count  uint64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+func NewHistogram() *Histogram {
+	return &Histogram{min: math.MaxInt64}
+}
+
+// Merge returns a new histogram holding the values of all the given histograms. Nil histograms are ignored.
+func Merge(histograms ...*Histogram) *Histogram {
+	merged := NewHistogram()
+	for _, h := range histograms {
+		merged.Merge(h)
+	}
+	return merged
+}
+
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[bucketIndex(v)]++
+	h.count++
+	h.sum += float64(v)
+	if v < h.min {
+		h.min = v
+	}
+	if v > h.max {
+		h.max = v
+	}
+}
+
+func (h *Histogram) Merge(other *Histogram) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.count += other.count
+	h.sum += other.sum
+	if other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+func (h *Histogram) Min() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.min)
+}
+
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.count))
+}
+
+// Quantile returns the value below which the fraction q of the recorded values fall, e.g. 0.99 for the 99th percentile.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	target := uint64(math.Ceil(q * float64(h.count)))
+	if target < 1 {
+		target = 1
+	}
+	if target > h.count {
+		target = h.count
+	}
+
+	var cumulative uint64
+	for i, c := range h.counts {
+		cumulative += c
+		if cumulative >= target {
+			v := bucketValue(i)
+			if v < h.min {
+				v = h.min
+			}
+			if v > h.max {
+				v = h.max
+			}
+			return time.Duration(v)
+		}
+	}
+	return time.Duration(h.max)
+}
+
+func bucketIndex(v int64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - subBucketBits
+	sub := int(v >> shift)
+	return subBucketCount + (shift-1)*subBucketHalf + (sub - subBucketHalf)
+}
+
+// Returns the midpoint of the range of values that are recorded in the bucket with the given index
+func bucketValue(index int) int64 {
+	if index < subBucketCount {
+		return int64(index)
+	}
+	k := index - subBucketCount
+	shift := k/subBucketHalf + 1
+	sub := int64(k%subBucketHalf + subBucketHalf)
+	return sub<<shift + (int64(1)<<shift)/2
+}
@@ -0,0 +1,108 @@
+package stats
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramEmpty(t *testing.T) {
+	h := NewHistogram()
+	if h.Count() != 0 || h.Min() != 0 || h.Max() != 0 || h.Mean() != 0 || h.Quantile(0.5) != 0 {
+		t.Errorf("empty histogram: count %d, min %v, max %v, mean %v, p50 %v", h.Count(), h.Min(), h.Max(), h.Mean(), h.Quantile(0.5))
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	// 1ms, 2ms, ..., 1000ms
+	h := NewHistogram()
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	if h.Count() != 1000 {
+		t.Errorf("count %d, want 1000", h.Count())
+	}
+	if h.Min() != time.Millisecond || h.Max() != time.Second {
+		t.Errorf("min %v, max %v, want 1ms and 1s", h.Min(), h.Max())
+	}
+	if h.Mean() != 500500*time.Microsecond {
+		t.Errorf("mean %v, want 500.5ms", h.Mean())
+	}
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{0.001, time.Millisecond},
+		{0.5, 500 * time.Millisecond},
+		{0.9, 900 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+		{0.999, 999 * time.Millisecond},
+		{1, time.Second},
+	}
+	for _, tt := range tests {
+		got := h.Quantile(tt.q)
+		if relativeError(got, tt.want) > 1.0/64 {
+			t.Errorf("quantile %v = %v, want %v within 1/64", tt.q, got, tt.want)
+		}
+	}
+}
+
+// Values below the sub-bucket count are recorded exactly, and values beyond are within the relative error bound
+func TestHistogramPrecision(t *testing.T) {
+	tests := []time.Duration{0, 1, 127, 128, 129, 1000, 12345, time.Millisecond + 1, 3 * time.Second, time.Hour, math.MaxInt64}
+	for _, d := range tests {
+		h := NewHistogram()
+		h.Record(d)
+		// Min and max clamp a single value to itself, so read the bucket value directly
+		got := time.Duration(bucketValue(bucketIndex(int64(d))))
+		if d < subBucketCount && got != d {
+			t.Errorf("value %d recorded as %d, want exact", d, got)
+		}
+		if relativeError(got, d) > 1.0/64 {
+			t.Errorf("value %v recorded as %v, beyond the relative error bound", d, got)
+		}
+		if h.Quantile(0.5) != d {
+			t.Errorf("single value %v has median %v", d, h.Quantile(0.5))
+		}
+	}
+}
+
+func TestHistogramNegative(t *testing.T) {
+	h := NewHistogram()
+	h.Record(-time.Second)
+	if h.Count() != 1 || h.Min() != 0 || h.Max() != 0 {
+		t.Errorf("negative value: count %d, min %v, max %v, want 1, 0, 0", h.Count(), h.Min(), h.Max())
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := NewHistogram(), NewHistogram()
+	for i := 1; i <= 10; i++ {
+		a.Record(time.Duration(i) * time.Millisecond)
+		b.Record(time.Duration(100+i) * time.Millisecond)
+	}
+	merged := Merge(a, nil, NewHistogram(), b)
+	if merged.Count() != 20 {
+		t.Errorf("count %d, want 20", merged.Count())
+	}
+	if merged.Min() != time.Millisecond || merged.Max() != 110*time.Millisecond {
+		t.Errorf("min %v, max %v, want 1ms and 110ms", merged.Min(), merged.Max())
+	}
+	if merged.Mean() != 55500*time.Microsecond {
+		t.Errorf("mean %v, want 55.5ms", merged.Mean())
+	}
+	if p50 := merged.Quantile(0.5); relativeError(p50, 10*time.Millisecond) > 1.0/64 {
+		t.Errorf("p50 %v, want 10ms", p50)
+	}
+	if a.Count() != 10 || b.Count() != 10 {
+		t.Errorf("merging changed the merged histograms")
+	}
+}
+
+func relativeError(got, want time.Duration) float64 {
+	if want == 0 {
+		return math.Abs(float64(got))
+	}
+	return math.Abs(float64(got-want)) / float64(want)
+}
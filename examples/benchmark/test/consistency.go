@@ -0,0 +1,22 @@
+package test
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InconsistencyError is returned when the players of a session return different results that should be the same
+type InconsistencyError struct {
+	What    string
+	Session string
+	// The players, grouped by the result they returned
+	Players [][]int
+}
+
+func (e *InconsistencyError) Error() string {
+	var groups []string
+	for _, group := range e.Players {
+		groups = append(groups, fmt.Sprint(group))
+	}
+	return fmt.Sprintf("players disagree on %s in session %s: players %s", e.What, e.Session, strings.Join(groups, " vs "))
+}
@@ -0,0 +1,111 @@
+package test
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/Blockdaemon/go-tsm-sdkv2/v70/tsm"
+	"golang.org/x/sync/errgroup"
+)
+
+// PlayerResult is the result of one player in a session run by RunClientsWithResults
+type PlayerResult[T any] struct {
+	Result T
+	Start  time.Time
+	End    time.Time
+	Err    error
+}
+
+func (r PlayerResult[T]) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// SessionResults holds the result of each player in a session, by player index
+type SessionResults[T any] map[int]PlayerResult[T]
+
+// RunClientsWithResults runs runFunc for each client like RunClientsContext, and returns the result, the start and end
+// time and the error of each player. As with RunClientsContext, the context passed to runFunc is cancelled as soon as
+// one player fails, and the returned error is the error of the player that failed first; the other players then
+// typically fail with a cancellation error. The results of all players are returned in any case.
+func RunClientsWithResults[T any](ctx context.Context, clients map[int]*tsm.Client, runFunc func(ctx context.Context, playerIndex int, client *tsm.Client) (T, error)) (SessionResults[T], error) {
+	var lock sync.Mutex
+	results := make(SessionResults[T], len(clients))
+	eg, ctx := errgroup.WithContext(ctx)
+	for i, c := range clients {
+		i, c := i, c
+		eg.Go(func() error {
+			start := time.Now()
+			result, err := runFunc(ctx, i, c)
+			end := time.Now()
+			lock.Lock()
+			results[i] = PlayerResult[T]{Result: result, Start: start, End: end, Err: err}
+			lock.Unlock()
+			if err != nil {
+				return &PlayerError{Player: i, Err: err}
+			}
+			return nil
+		})
+	}
+	return results, eg.Wait()
+}
+
+// Players returns the player indices in ascending order
+func (r SessionResults[T]) Players() []int {
+	players := make([]int, 0, len(r))
+	for playerIndex := range r {
+		players = append(players, playerIndex)
+	}
+	sort.Ints(players)
+	return players
+}
+
+// Values returns the results of the players, ordered by player index
+func (r SessionResults[T]) Values() []T {
+	values := make([]T, 0, len(r))
+	for _, playerIndex := range r.Players() {
+		values = append(values, r[playerIndex].Result)
+	}
+	return values
+}
+
+// AllEqual returns the result that all players agree on. If the players disagree, it returns an InconsistencyError
+// that groups the players by the result they returned. The session identifies the session, or the request, in the
+// error.
+func (r SessionResults[T]) AllEqual(what, session string, equal func(a, b T) bool) (T, error) {
+	// Players returning the same result end up in the same group, with the groups ordered by their lowest player
+	var groups [][]int
+	for _, playerIndex := range r.Players() {
+		found := false
+		for i, group := range groups {
+			if equal(r[group[0]].Result, r[playerIndex].Result) {
+				groups[i] = append(group, playerIndex)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, []int{playerIndex})
+		}
+	}
+
+	var zero T
+	if len(groups) == 0 {
+		return zero, nil
+	}
+	if len(groups) > 1 {
+		return zero, &InconsistencyError{What: what, Session: session, Players: groups}
+	}
+	return r[groups[0][0]].Result, nil
+}
+
+// CombineECDSASignatures combines the partial signatures returned by the players of an ECDSA signing session
+func CombineECDSASignatures(messageHash []byte, partialSignatures SessionResults[[]byte]) (*tsm.ECDSASignature, error) {
+	return tsm.ECDSAFinalizeSignature(messageHash, partialSignatures.Values())
+}
+
+// CombineSchnorrSignatures combines the partial signatures returned by the players of a Schnorr signing session
+func CombineSchnorrSignatures(message []byte, partialSignatures SessionResults[[]byte]) ([]byte, error) {
+	return tsm.SchnorrFinalizeSignature(message, partialSignatures.Values())
+}
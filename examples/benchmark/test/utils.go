@@ -1,6 +1,7 @@
 package test
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
@@ -29,14 +30,37 @@ func CreateSessionConfig(clients map[int]*tsm.Client) *tsm.SessionConfig {
 	return tsm.NewSessionConfig(tsm.GenerateSessionID(), players, nil)
 }
 
+// PlayerError is returned by RunClients and its variants when a player fails
+type PlayerError struct {
+	Player int
+	Err    error
+}
+
+func (e *PlayerError) Error() string {
+	return fmt.Sprintf("client %d failed: %v", e.Player, e.Err)
+}
+
+func (e *PlayerError) Unwrap() error {
+	return e.Err
+}
+
+// RunClients runs runFunc for each client concurrently, and returns the first error returned by any of them
 func RunClients(clients map[int]*tsm.Client, runFunc func(playerIndex int, client *tsm.Client) error) error {
-	var eg errgroup.Group
+	return RunClientsContext(context.Background(), clients, func(_ context.Context, playerIndex int, client *tsm.Client) error {
+		return runFunc(playerIndex, client)
+	})
+}
+
+// RunClientsContext is like RunClients, but passes each client a context derived from ctx that is cancelled as soon as
+// one of the clients fails, so that the other players of the session give up instead of waiting for the failed player
+// until their own timeout.
+func RunClientsContext(ctx context.Context, clients map[int]*tsm.Client, runFunc func(ctx context.Context, playerIndex int, client *tsm.Client) error) error {
+	eg, ctx := errgroup.WithContext(ctx)
 	for i, c := range clients {
 		i, c := i, c
 		eg.Go(func() error {
-			err := runFunc(i, c)
-			if err != nil {
-				return fmt.Errorf("client %d failed: %v", i, err)
+			if err := runFunc(ctx, i, c); err != nil {
+				return &PlayerError{Player: i, Err: err}
 			}
 			return nil
 		})